@@ -1,34 +1,100 @@
 package boilerplate
 
 import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
-	"testing"
+	"time"
+
+	"github.com/sonalys/fake/matchers"
 )
 
 type (
+	// TestingT is the minimal interface used by mocks to report failures.
+	// It's satisfied by testing.TB, so *testing.T, *testing.B and *testing.F can all be used.
+	TestingT interface {
+		Helper()
+		Errorf(format string, args ...any)
+		Fatalf(format string, args ...any)
+		Cleanup(func())
+	}
+
 	// Config represents the configuration for all the functions passed to the On... function.
+	// Its cardinality sets how many times the function group is called, note that if more than 1 function is given,
+	// all the functions should be called n times.
+	// Groups are called exactly 1 time by default.
 	Config interface {
-		// Repeat sets how many times the function group should be called, note that if more than 1 function is given,
-		// all the functions should repeat n times.
-		// Repeat is 1 by default, meaning that functions can only be called 1 time.
-		// Set Repeat(-1) to allow the group to repeat indefinitely.
+		// Repeat sets how many times the function group should be called, it's the same as Times.
+		// Set Repeat(-1) to allow the group to repeat indefinitely, after being called at least once.
+		// Repeat(0) keeps the default single call, use Never to forbid calls instead.
 		Repeat(times int)
+		// Times sets the group to be called exactly n times.
+		Times(n int)
+		// AtLeast sets the group to be called n or more times.
+		AtLeast(n int)
+		// AtMost sets the group to be called up to n times, so it's not required by AssertExpectations.
+		AtMost(n int)
+		// Never fails the test if the group is called, catching the calls it matches.
+		Never()
+		// Between sets the group to be called from min to max times.
+		Between(min, max int)
 		// Maybe sets the group as not required for AssertExpectations,
 		// meaning that the function group will not fail the test if not called.
 		Maybe()
+		// Wait blocks until the group is called as expected, returning an error if the timeout is reached first.
+		// It's used when calls come from other goroutines.
+		Wait(timeout time.Duration) error
+		// asStep returns the config as a sequence step, used by InOrder.
+		asStep() sequenceStep
+	}
+
+	// Expectation is a Config that can also define which functions are called when it's met.
+	Expectation[T any] interface {
+		Config
+		// Do replaces the function group called when the expectation is met.
+		// Expectations without functions return zero values.
+		Do(funcs ...T) Config
 	}
 
 	Call[T any] struct {
-		lock   *sync.Mutex
-		repeat int
-		maybe  bool
-		cur    int
-		hooks  []T
+		// lock is owned by the mock keeping the deck, so a single mutex guards all of its cards.
+		lock *sync.Mutex
+		// name is the mock and method name used in failures.
+		name string
+		// site is the file:line location where the call was registered.
+		site string
+		// min and max are how many times the group should be called, a negative max means no limit.
+		min, max int
+		maybe    bool
+		// calls counts the functions drawn from the group, including the calls over its limit.
+		calls int
+		hooks []T
+		// args are the matchers for each argument, nil means any arguments are accepted.
+		args []matchers.Matcher
+		// delay is how long calls wait before running, block makes them wait until their context is done.
+		delay    time.Duration
+		block    bool
+		sequence *Sequence
+		step     int
+		// owner is the mock the call was registered on, depth is how many scopes it's nested in.
+		owner *Mock[T]
+		depth int
 	}
 
 	Mock[T any] struct {
-		lock  *sync.Mutex
-		calls []*Call[T]
+		// lock guards the deck, its cards and the history, only the root mock's lock is used.
+		lock     sync.Mutex
+		t        TestingT
+		name     string
+		calls    []*Call[T]
+		history  []*Invocation
+		fallback Fallback
+		// parent is the mock a scope was created from, the deck and history are kept by the root mock.
+		parent *Mock[T]
+		// scopes are the scopes not yet finished, the last one receives the failures from unexpected calls.
+		scopes []*Mock[T]
 	}
 )
 
@@ -38,100 +104,311 @@ var (
 	RepeatForever int = -1
 )
 
-// setupLocker is a sync.Once func to configure sync.Mutex in case newMock wasn't called.
-func setupLocker() *sync.Mutex { return &sync.Mutex{} }
-
 func (c *Call[T]) Repeat(times int) {
-	c.lock = sync.OnceValue(setupLocker)()
+	switch {
+	case times < 0:
+		c.AtLeast(1)
+	case times == 0:
+		c.Times(1)
+	default:
+		c.Times(times)
+	}
+}
+
+func (c *Call[T]) Times(n int) { c.Between(n, n) }
+
+func (c *Call[T]) AtLeast(n int) { c.Between(n, -1) }
+
+func (c *Call[T]) AtMost(n int) { c.Between(0, n) }
+
+func (c *Call[T]) Never() { c.Between(0, 0) }
+
+// Between sets the group cardinality, a negative max means no limit.
+// It panics if min is negative or greater than max.
+func (c *Call[T]) Between(min, max int) {
+	if min < 0 || max >= 0 && max < min {
+		panic(fmt.Sprintf("invalid cardinality for %s: between %d and %d calls", c, min, max))
+	}
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	c.repeat = times
+	c.min, c.max = min, max
 }
 
 func (c *Call[T]) Maybe() {
-	c.lock = sync.OnceValue(setupLocker)()
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	c.maybe = true
 }
 
-func NewMock[T any](t *testing.T) Mock[T] {
-	value := Mock[T]{
-		lock: sync.OnceValue(setupLocker)(),
+func (c *Call[T]) Do(funcs ...T) Config {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(funcs) > 0 {
+		c.hooks = funcs
+	}
+	return c
+}
+
+func (c *Call[T]) asStep() sequenceStep { return c }
+
+func (c *Call[T]) join(s *Sequence, step int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.sequence = s
+	c.step = step
+}
+
+// satisfied returns true if the call is not required anymore by AssertExpectations.
+// It must be called without the lock held.
+func (c *Call[T]) satisfied() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.maybe || c.calls >= c.min*len(c.hooks)
+}
+
+// String returns the method name, or the function type for calls from mocks without name.
+func (c *Call[T]) String() string {
+	if c.name != "" {
+		return c.name
+	}
+	var f T
+	return fmt.Sprintf("%T", f)
+}
+
+// exhausted reports whether the group was called its maximum times, it must be called with the lock held.
+func (c *Call[T]) exhausted() bool {
+	return c.max >= 0 && c.calls >= c.max*len(c.hooks)
+}
+
+// cardinality describes how many calls are expected, it must be called with the lock held.
+func (c *Call[T]) cardinality() string {
+	minCalls, maxCalls := c.min*len(c.hooks), c.max*len(c.hooks)
+	if c.maybe {
+		minCalls = 0
+	}
+	switch {
+	case maxCalls < 0:
+		return fmt.Sprintf("at least %d", minCalls)
+	case minCalls == maxCalls:
+		return fmt.Sprintf("exactly %d", minCalls)
+	case minCalls == 0:
+		return fmt.Sprintf("at most %d", maxCalls)
+	default:
+		return fmt.Sprintf("between %d and %d", minCalls, maxCalls)
+	}
+}
+
+// Match returns how many arguments are matched by the call, and if all of them matched.
+func (c *Call[T]) Match(args []any) (int, bool) {
+	if c.args == nil {
+		return len(args), true
+	}
+	var matched int
+	for i, arg := range args {
+		if i < len(c.args) && c.args[i].Match(arg) {
+			matched++
+		}
+	}
+	return matched, matched == len(args) && len(args) == len(c.args)
+}
+
+// capture stores the arguments on the captors from the call matchers.
+func (c *Call[T]) capture(args []any) {
+	for i, matcher := range c.args {
+		if captor, ok := matcher.(capturer); ok && i < len(args) {
+			captor.capture(args[i])
+		}
+	}
+}
+
+// NewMock creates the deck of functions for a method, the mock and method names are used in failures.
+// Expectations are asserted when the test finishes.
+func NewMock[T any](t TestingT, mockName, method string) *Mock[T] {
+	t.Helper()
+	mock := &Mock[T]{
+		t:    t,
+		name: fmt.Sprintf("%s.%s", mockName, method),
 	}
 	t.Cleanup(func() {
-		value.AssertExpectations(t)
+		t.Helper()
+		mock.AssertExpectations(t)
 	})
-	return value
+	return mock
 }
 
-// AssertExpectations asserts that all expected function calls have been called.
+// AssertExpectations asserts that all registered groups were called as many times as expected.
+// On failure, a table with all the expectations of the method is reported, with their calls and registration site.
 // Returns true if all expectations were met, otherwise returns false.
-func (c *Mock[T]) AssertExpectations(t *testing.T) bool {
-	c.lock = sync.OnceValue(setupLocker)()
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	var missingCalls int
-	for _, call := range c.calls {
-		call.lock.Lock()
-		if call.maybe {
+func (c *Mock[T]) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	root := c.root()
+	root.lock.Lock()
+	rows := make([]reportRow, 0, len(root.calls))
+	met := true
+	for _, call := range root.calls {
+		if !c.owns(call) {
 			continue
 		}
-		if call.repeat <= 0 {
-			call.repeat = 1
-		}
-		missingCalls += call.repeat*len(call.hooks) - call.cur
-		call.lock.Unlock()
+		row := call.reportRow()
+		met = met && row.met
+		rows = append(rows, row)
 	}
-	if missingCalls > 0 {
-		t.Errorf("%d more calls expected to func %T", missingCalls, c.calls[0].hooks[0])
-		return false
+	name := root.name
+	root.lock.Unlock()
+	if !met {
+		t.Errorf("%s", printReport(name, rows))
 	}
-	return true
+	return met
 }
 
-// Draw is a card Draw design, in which each call determines when to get removed from the deck.
-// if repeat == 0, the call gets removed from deck.
-// setting repeat = -1 will skip this condition, allowing it to repeat indefinitely through the group.
-func (c *Call[T]) Draw() (f T, empty bool) {
-	c.lock = sync.OnceValue(setupLocker)()
+// Draw is a card Draw design, in which each call counts how many times the group was drawn.
+// It returns the next function from the group, and false if the group was already called its maximum times.
+// Calls over the limit are still counted, so they are reported by AssertExpectations.
+func (c *Call[T]) Draw() (f T, ok bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	f = c.hooks[c.cur]
-	c.cur++
-	if c.cur == len(c.hooks) {
-		c.repeat--
-		c.cur = c.cur % len(c.hooks)
-	}
-	return f, c.repeat == -1
+	return c.next()
+}
+
+// next draws the next function from the group, it must be called with the lock held.
+func (c *Call[T]) next() (f T, ok bool) {
+	ok = !c.exhausted()
+	f = c.hooks[c.calls%len(c.hooks)]
+	c.calls++
+	return f, ok
 }
 
 // Call returns a func of type T and a bool from the deck.
-// It either returns (func, true) or (nil, false).
-func (c *Mock[T]) Call() (*T, bool) {
-	c.lock = sync.OnceValue(setupLocker)()
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	if len(c.calls) == 0 {
-		return nil, false
+// The first card matching the given arguments is drawn.
+// It either returns (func, true) or (nil, false) when no card matches the arguments.
+// Unmatched calls should be handled with Unexpected.
+func (c *Mock[T]) Call(args ...any) (*T, bool) {
+	_, f, ok := c.draw(args)
+	return f, ok
+}
+
+// draw removes a card matching the arguments from the deck, returning it with its function.
+// Sequences and captors are updated after the deck is unlocked, since they can lock the decks from other mocks.
+func (c *Mock[T]) draw(args []any) (*Call[T], *T, bool) {
+	// Waiters are notified after the mock is unlocked.
+	defer notify()
+	call, f, step, ok := c.root().drawLocked(args)
+	if !ok {
+		return nil, nil, false
 	}
-	first, empty := c.calls[0].Draw()
-	if empty {
-		c.calls = c.calls[1:]
+	if step.sequence != nil {
+		step.sequence.visit(step.index)
 	}
-	return &first, true
+	call.capture(args)
+	return call, &f, true
 }
 
-// Append creates a new card for the group of functions given, returning Config.
-// With Config you can configure the group expectations.
-func (c *Mock[T]) Append(f ...T) Config {
-	c.lock = sync.OnceValue(setupLocker)()
+// sequencePosition is the position of a card in its sequence, read with the lock held since InOrder can run concurrently.
+type sequencePosition struct {
+	sequence *Sequence
+	index    int
+}
+
+// drawLocked draws a card matching the arguments from the deck of the root mock c.
+func (c *Mock[T]) drawLocked(args []any) (*Call[T], T, sequencePosition, bool) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
+	// The first card over its limit counts the call when no other card accepts it.
+	var overcall *Call[T]
+	for _, call := range c.calls {
+		if _, ok := call.Match(args); !ok {
+			continue
+		}
+		if call.exhausted() {
+			overcall = cmp.Or(overcall, call)
+			// Never cards catch the calls they match, so they are not drawn from later cards.
+			if call.max == 0 {
+				break
+			}
+			continue
+		}
+		f, _ := call.next()
+		return call, f, sequencePosition{call.sequence, call.step}, true
+	}
+	if overcall != nil {
+		overcall.next()
+	}
+	var zero T
+	return nil, zero, sequencePosition{}, false
+}
+
+// describeMismatch returns a diff between the given arguments and the closest candidate from the deck.
+// It must be called with the lock held.
+// If the closest candidate accepts the arguments, its cardinality is described instead.
+func (c *Mock[T]) describeMismatch(args []any) string {
+	closest, best := c.calls[0], -1
+	for _, call := range c.calls {
+		if matched, _ := call.Match(args); matched > best {
+			closest, best = call, matched
+		}
+	}
+	// Calls matching a card over its limit are only unexpected because of the cardinality.
+	if _, ok := closest.Match(args); ok {
+		return fmt.Sprintf("%s received %d calls, expected %s\n", closest.describe(), closest.calls, closest.cardinality())
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "closest candidate:\n")
+	for i, arg := range args {
+		if i >= len(closest.args) {
+			fmt.Fprintf(b, "\t+ a%d: %#v\n", i, arg)
+			continue
+		}
+		if matcher := closest.args[i]; !matcher.Match(arg) {
+			fmt.Fprintf(b, "\t- a%d: %s\n", i, matcher)
+			fmt.Fprintf(b, "\t+ a%d: %#v\n", i, arg)
+			continue
+		}
+		fmt.Fprintf(b, "\t  a%d: %#v\n", i, arg)
+	}
+	return b.String()
+}
+
+// Append creates a new card for the group of functions given, returning Expectation.
+// With Expectation you can configure the group expectations.
+// If no function is given, the card returns zero values until Do is called.
+func (c *Mock[T]) Append(f ...T) Expectation[T] {
+	return c.append(callerSite(2), nil, f)
+}
+
+// Expect creates a new card that is only drawn by calls matching the given arguments.
+// Each argument can be either a literal value or a matchers.Matcher.
+func (c *Mock[T]) Expect(args ...any) Expectation[T] {
+	argMatchers := make([]matchers.Matcher, 0, len(args))
+	for _, arg := range args {
+		argMatchers = append(argMatchers, matchers.Of(arg))
+	}
+	return c.append(callerSite(2), argMatchers, nil)
+}
+
+// append adds a card to the deck, site is where it was registered from.
+// Cards from scopes are drawn before the cards from their parents.
+func (c *Mock[T]) append(site string, args []matchers.Matcher, f []T) *Call[T] {
+	root := c.root()
+	root.lock.Lock()
+	defer root.lock.Unlock()
+	if len(f) == 0 {
+		f = make([]T, 1)
+	}
 	call := &Call[T]{
+		name:  c.name,
+		site:  site,
+		min:   1,
+		max:   1,
 		hooks: f,
-		lock:  &sync.Mutex{},
+		args:  args,
+		lock:  &root.lock,
+		owner: c,
+		depth: c.depth(),
+	}
+	i := slices.IndexFunc(root.calls, func(other *Call[T]) bool { return other.depth < call.depth })
+	if i < 0 {
+		i = len(root.calls)
 	}
-	c.calls = append(c.calls, call)
+	root.calls = slices.Insert(root.calls, i, call)
 	return call
 }
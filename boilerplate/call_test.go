@@ -0,0 +1,174 @@
+package boilerplate
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sonalys/fake/matchers"
+	"github.com/stretchr/testify/require"
+)
+
+var _ TestingT = testing.TB(nil)
+
+// reporter is a TestingT implementation that records failures instead of failing the test.
+type reporter struct {
+	lock     sync.Mutex
+	errors   []string
+	fatals   []string
+	cleanups []func()
+}
+
+func (r *reporter) Helper() {}
+
+func (r *reporter) Errorf(format string, args ...any) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *reporter) Fatalf(format string, args ...any) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.fatals = append(r.fatals, fmt.Sprintf(format, args...))
+}
+
+func (r *reporter) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func Test_Mock_Expect(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(string, int) int](r, "CacheMock", "Get")
+	mock.Expect("a", matchers.Any()).Do(func(string, int) int { return 1 })
+	mock.Expect(matchers.Regexp("^b"), 2).Do(func(string, int) int { return 2 })
+	mock.Expect("c", matchers.Not(0))
+
+	f, ok := mock.Call("b", 2)
+	require.True(t, ok)
+	require.Equal(t, 2, (*f)("b", 2))
+
+	f, ok = mock.Call("a", 10)
+	require.True(t, ok)
+	require.Equal(t, 1, (*f)("a", 10))
+
+	// Expectations without functions return nil hooks.
+	f, ok = mock.Call("c", 1)
+	require.True(t, ok)
+	require.Nil(t, *f)
+
+	_, ok = mock.Call("a", 10)
+	require.False(t, ok)
+	require.Empty(t, r.fatals)
+}
+
+func Test_Mock_describeMismatch(t *testing.T) {
+	var mock Mock[func(string, any)]
+	mock.Expect("a", 1)
+	mock.Expect("b", matchers.Len(2))
+
+	got := mock.describeMismatch([]any{"b", "x"})
+	require.Equal(t, "closest candidate:\n"+
+		"\t  a0: \"b\"\n"+
+		"\t- a1: Len(2)\n"+
+		"\t+ a1: \"x\"\n", got)
+}
+
+func Test_Mock_cardinality(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(string)](r, "CacheMock", "Get")
+	mock.Expect("times").Times(2)
+	mock.Expect("atLeast").AtLeast(2)
+	mock.Expect("atMost").AtMost(1)
+	mock.Expect("between").Between(1, 2)
+	mock.Expect("never").Never()
+	mock.Expect(matchers.Any()).Repeat(RepeatForever)
+
+	call := func(arg string) bool {
+		_, ok := mock.Call(arg)
+		return ok
+	}
+	require.True(t, call("times"))
+	require.True(t, call("times"))
+	// Calls over the limit are drawn from the next card accepting them.
+	require.True(t, call("times"))
+	require.True(t, call("atLeast"))
+	require.True(t, call("between"))
+	require.True(t, call("between"))
+	// Never cards catch their calls before the last card.
+	require.False(t, call("never"))
+
+	require.False(t, mock.AssertExpectations(r))
+	require.Len(t, r.errors, 1)
+	rows := strings.Split(r.errors[0], "\n")
+	require.Equal(t, []string{"CacheMock.Get(Eq(\"atLeast\"))", "at", "least", "2", "1", "1", "missing"}, strings.Fields(rows[3])[:7])
+	require.Equal(t, []string{"CacheMock.Get(Eq(\"never\"))", "exactly", "0", "1", "1", "too", "many"}, strings.Fields(rows[6])[:7])
+}
+
+func Test_Mock_overcall(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(int)](r, "CacheMock", "Get")
+	mock.Append(func(int) {}, func(int) {}).Repeat(2)
+
+	for range 4 {
+		_, ok := mock.Call(1)
+		require.True(t, ok)
+	}
+	_, ok := mock.Call(1)
+	require.False(t, ok)
+	require.Equal(t, "CacheMock.Get received 5 calls, expected exactly 4\n", mock.describeMismatch([]any{1}))
+
+	// Expectations are asserted when the test finishes.
+	for _, cleanup := range r.cleanups {
+		cleanup()
+	}
+	require.Len(t, r.errors, 1)
+	require.Contains(t, r.errors[0], "1 too many")
+}
+
+func Test_Mock_Repeat(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(int)](r, "CacheMock", "Get")
+	// Repeat(0) keeps the default single call, instead of forbidding calls like Never.
+	mock.Expect(1).Repeat(0)
+
+	_, ok := mock.Call(1)
+	require.True(t, ok)
+	require.True(t, mock.AssertExpectations(r))
+	_, ok = mock.Call(1)
+	require.False(t, ok)
+}
+
+func Test_Mock_concurrent(t *testing.T) {
+	const goroutines, calls = 8, 50
+	r := &reporter{}
+	mock := NewMock[func(int) int](r, "CacheMock", "Get")
+	mock.SetFallback(FallbackZero)
+	var wg sync.WaitGroup
+	for i := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range calls {
+				mock.Append(func(n int) int { return n }).Maybe()
+				mock.Expect(i).AtMost(1)
+				invocation := mock.Record(j)
+				if f, ok := mock.Call(j); !ok {
+					mock.Unexpected("Get", j)
+				} else if *f != nil {
+					invocation.SetResults((*f)(j))
+				}
+				// Cards can be asserted before being configured, so only races are checked here.
+				mock.AssertExpectations(&reporter{})
+				mock.Invocations()
+				mock.pending()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Len(t, mock.Invocations(), goroutines*calls)
+	require.True(t, mock.AssertExpectations(r))
+	require.Empty(t, r.errors)
+	require.Empty(t, r.fatals)
+}
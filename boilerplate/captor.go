@@ -0,0 +1,88 @@
+package boilerplate
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+type (
+	// ArgCaptor is a matcher that collects the arguments of the calls drawing its expectation, for later assertions:
+	//
+	//	captor := boilerplate.NewArgCaptor[User]()
+	//	mock.ExpectCreate(matchers.Any(), captor)
+	//	...
+	//	require.Equal(t, "john", captor.Last().Name)
+	//
+	// It matches any value of type T. For variadic parameters, it also matches []T, capturing each element.
+	ArgCaptor[T any] struct {
+		lock   sync.Mutex
+		values []T
+	}
+
+	// capturer is implemented by matchers that store the arguments from drawn calls.
+	capturer interface {
+		capture(value any)
+	}
+)
+
+// NewArgCaptor creates an empty captor for arguments of type T.
+func NewArgCaptor[T any]() *ArgCaptor[T] {
+	return &ArgCaptor[T]{}
+}
+
+func (c *ArgCaptor[T]) Match(value any) bool {
+	if _, ok := convert[T](value); ok {
+		return true
+	}
+	_, ok := value.([]T)
+	return ok
+}
+
+func (c *ArgCaptor[T]) String() string {
+	return fmt.Sprintf("ArgCaptor[%s]", reflect.TypeFor[T]())
+}
+
+func (c *ArgCaptor[T]) capture(value any) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if v, ok := convert[T](value); ok {
+		c.values = append(c.values, v)
+		return
+	}
+	if variadic, ok := value.([]T); ok {
+		c.values = append(c.values, variadic...)
+	}
+}
+
+// Last returns the last captured value, or the zero value if nothing was captured.
+func (c *ArgCaptor[T]) Last() T {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var last T
+	if len(c.values) > 0 {
+		last = c.values[len(c.values)-1]
+	}
+	return last
+}
+
+// All returns all captured values, in the order they were received.
+func (c *ArgCaptor[T]) All() []T {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return slices.Clone(c.values)
+}
+
+// convert asserts the value as T. Untyped nil is also accepted when T is nilable, like a pointer or an interface.
+func convert[T any](value any) (T, bool) {
+	v, ok := value.(T)
+	if ok || value != nil {
+		return v, ok
+	}
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+		return v, true
+	}
+	return v, false
+}
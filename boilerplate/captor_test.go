@@ -0,0 +1,40 @@
+package boilerplate
+
+import (
+	"testing"
+
+	"github.com/sonalys/fake/matchers"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	Name string
+}
+
+func Test_ArgCaptor(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(*user, ...string)](r, "UserDBMock", "Create")
+	users, tags := NewArgCaptor[*user](), NewArgCaptor[string]()
+	mock.Expect(users, tags).Repeat(RepeatForever)
+	mock.Expect(matchers.Any(), matchers.Any())
+
+	require.Nil(t, users.Last())
+	_, ok := mock.Call(&user{Name: "john"}, []string{"a", "b"})
+	require.True(t, ok)
+	_, ok = mock.Call(nil, []string(nil))
+	require.True(t, ok)
+
+	require.Equal(t, []*user{{Name: "john"}, nil}, users.All())
+	require.Nil(t, users.Last())
+	// Variadic arguments are captured one by one.
+	require.Equal(t, []string{"a", "b"}, tags.All())
+	require.Equal(t, "ArgCaptor[*boilerplate.user]", users.String())
+
+	// Values from other types are not matched, nor captured.
+	numbers := NewArgCaptor[[]int]()
+	mock = NewMock[func(*user, ...string)](r, "UserDBMock", "Create")
+	mock.Expect(matchers.Any(), numbers)
+	_, ok = mock.Call(nil, []string{"a"})
+	require.False(t, ok)
+	require.Empty(t, numbers.All())
+}
@@ -0,0 +1,57 @@
+package boilerplate
+
+import (
+	"context"
+	"time"
+)
+
+// Delay makes the calls drawing the expectation wait for d before running, or until their context is done.
+// It's only observed by methods receiving a context.Context as their first parameter.
+func Delay[T any](e Expectation[T], d time.Duration) {
+	if call, ok := e.(*Call[T]); ok {
+		call.lock.Lock()
+		defer call.lock.Unlock()
+		call.delay = d
+	}
+}
+
+// Block makes the calls drawing the expectation wait until their context is done.
+// It's only observed by methods receiving a context.Context as their first parameter.
+func Block[T any](e Expectation[T]) {
+	if call, ok := e.(*Call[T]); ok {
+		call.lock.Lock()
+		defer call.lock.Unlock()
+		call.block = true
+	}
+}
+
+// CallContext is like Call, but the drawn card waits for its delay before being returned.
+// If ctx is done first, its error is returned, and the card shouldn't be called.
+func (c *Mock[T]) CallContext(ctx context.Context, args ...any) (*T, bool, error) {
+	call, f, ok := c.draw(args)
+	if !ok {
+		return nil, false, nil
+	}
+	return f, true, call.wait(ctx)
+}
+
+// wait blocks for the call delay, returning the context error if it's done first.
+func (c *Call[T]) wait(ctx context.Context) error {
+	c.lock.Lock()
+	delay, block := c.delay, c.block
+	c.lock.Unlock()
+	switch {
+	case block:
+		<-ctx.Done()
+		return ctx.Err()
+	case delay > 0:
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
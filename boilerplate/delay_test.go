@@ -0,0 +1,39 @@
+package boilerplate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Mock_CallContext(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(context.Context)](r, "FetcherMock", "Fetch")
+	Block(mock.Append())
+	Delay(mock.Append(), 10*time.Millisecond)
+	Delay(mock.Append(), time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, ok, err := mock.CallContext(ctx, ctx)
+	require.True(t, ok)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	start := time.Now()
+	_, ok, err = mock.CallContext(context.Background(), context.Background())
+	require.True(t, ok)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, ok, err = mock.CallContext(ctx, ctx)
+	require.True(t, ok)
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, ok, err = mock.CallContext(ctx, ctx)
+	require.False(t, ok)
+	require.NoError(t, err)
+}
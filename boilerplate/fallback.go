@@ -0,0 +1,89 @@
+package boilerplate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Fallback defines how a mock handles unexpected calls, when no registered function matches them.
+type Fallback int
+
+const (
+	// FallbackPanic panics with the call description, it's the default behavior.
+	FallbackPanic Fallback = iota
+	// FallbackFatal fails the test with t.Fatalf.
+	// It must only be used when the mock is called from the test goroutine, otherwise the caller exits without returning.
+	FallbackFatal
+	// FallbackError fails the test with t.Errorf, returning zero values.
+	FallbackError
+	// FallbackZero silently returns zero values.
+	FallbackZero
+)
+
+var fallbackNames = []string{"panic", "fatal", "error", "zero"}
+
+func (f Fallback) String() string {
+	if int(f) < len(fallbackNames) {
+		return fallbackNames[f]
+	}
+	return fmt.Sprintf("Fallback(%d)", int(f))
+}
+
+// ParseFallback parses a fallback from its name: panic, fatal, error or zero.
+func ParseFallback(name string) (Fallback, error) {
+	for i, fallbackName := range fallbackNames {
+		if fallbackName == name {
+			return Fallback(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid fallback %q, expected one of %s", name, strings.Join(fallbackNames, ", "))
+}
+
+// UnmarshalText parses the fallback from its name, allowing it to be used in configuration files.
+func (f *Fallback) UnmarshalText(text []byte) (err error) {
+	*f, err = ParseFallback(string(text))
+	return err
+}
+
+// SetFallback sets how unexpected calls are handled by the mock.
+func (c *Mock[T]) SetFallback(fallback Fallback) {
+	c = c.root()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.fallback = fallback
+}
+
+// Unexpected handles a call to method that didn't match any card from the deck, according to the mock fallback.
+// If the deck is not empty, the failure shows the difference to the closest candidate.
+// Mocks created without NewMock always panic, since there is no test to report to.
+func (c *Mock[T]) Unexpected(method string, args ...any) {
+	c = c.root()
+	c.lock.Lock()
+	formattedArgs := make([]string, 0, len(args))
+	for _, arg := range args {
+		formattedArgs = append(formattedArgs, fmt.Sprintf("%#v", arg))
+	}
+	msg := fmt.Sprintf("unexpected call %s(%s)", method, strings.Join(formattedArgs, ", "))
+	if len(c.calls) > 0 {
+		msg = fmt.Sprintf("%s\n%s", msg, c.describeMismatch(args))
+	}
+	t, fallback := c.t, c.fallback
+	// Failures are reported to the innermost scope, which is the test running.
+	if len(c.scopes) > 0 {
+		t = c.scopes[len(c.scopes)-1].t
+	}
+	c.lock.Unlock()
+
+	if t == nil {
+		panic(msg)
+	}
+	t.Helper()
+	switch fallback {
+	case FallbackPanic:
+		panic(msg)
+	case FallbackFatal:
+		t.Fatalf("%s", msg)
+	case FallbackError:
+		t.Errorf("%s", msg)
+	}
+}
@@ -0,0 +1,45 @@
+package boilerplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Mock_Unexpected(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(string)](r, "UserDBMock", "Login")
+	mock.Expect("a")
+
+	// Mocks panic by default.
+	require.PanicsWithValue(t, "unexpected call Login(\"b\")\nclosest candidate:\n\t- a0: Eq(\"a\")\n\t+ a0: \"b\"\n", func() {
+		mock.Unexpected("Login", "b")
+	})
+
+	mock.SetFallback(FallbackFatal)
+	mock.Unexpected("Login", "b")
+	require.Equal(t, []string{"unexpected call Login(\"b\")\nclosest candidate:\n\t- a0: Eq(\"a\")\n\t+ a0: \"b\"\n"}, r.fatals)
+
+	mock.SetFallback(FallbackError)
+	mock.Unexpected("Login", "b")
+	require.Len(t, r.errors, 1)
+
+	mock.SetFallback(FallbackZero)
+	mock.Unexpected("Login", "b")
+	require.Len(t, r.errors, 1)
+	require.Len(t, r.fatals, 1)
+
+	// Mocks without a test always panic.
+	var empty Mock[func()]
+	require.PanicsWithValue(t, "unexpected call Logout()", func() { empty.Unexpected("Logout") })
+}
+
+func Test_ParseFallback(t *testing.T) {
+	for _, fallback := range []Fallback{FallbackPanic, FallbackFatal, FallbackError, FallbackZero} {
+		got, err := ParseFallback(fallback.String())
+		require.NoError(t, err)
+		require.Equal(t, fallback, got)
+	}
+	_, err := ParseFallback("ignore")
+	require.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package boilerplate
+
+import (
+	"bytes"
+	"cmp"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Invocation is the record of a call received by a mock.
+type Invocation struct {
+	// Method is the name of the method called, only set by Timeline.
+	Method  string
+	Args    []any
+	Results []any
+	// Goroutine is the id of the goroutine that made the call.
+	Goroutine uint64
+	Time      time.Time
+	// Caller is the file:line location of the call.
+	Caller string
+
+	seq  uint64
+	lock *sync.Mutex
+}
+
+// invocationSeq gives a global order to invocations, so calls from different mocks can be merged in a timeline.
+var invocationSeq atomic.Uint64
+
+// Record stores a new invocation with the given arguments into the mock history.
+// It should be called by the mock method, so the caller is correctly registered.
+func (c *Mock[T]) Record(args ...any) *Invocation {
+	invocation := &Invocation{
+		Args:      args,
+		Goroutine: goroutineID(),
+		Time:      time.Now(),
+		seq:       invocationSeq.Add(1),
+		lock:      &sync.Mutex{},
+	}
+	invocation.Caller = callerSite(2)
+	// Waiters are notified after the mock is unlocked.
+	defer notify()
+	c = c.root()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.history = append(c.history, invocation)
+	return invocation
+}
+
+// SetResults stores the values returned by the invocation.
+func (i *Invocation) SetResults(results ...any) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.Results = results
+}
+
+// Invocations returns a copy of all invocations received by the mock, in order.
+// Invocations still in progress don't have results.
+func (c *Mock[T]) Invocations() []Invocation {
+	c = c.root()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	resp := make([]Invocation, 0, len(c.history))
+	for _, invocation := range c.history {
+		invocation.lock.Lock()
+		resp = append(resp, *invocation)
+		invocation.lock.Unlock()
+	}
+	return resp
+}
+
+// Timeline merges the invocations from many methods in the order they were called.
+// The map keys are used as the invocations method names.
+func Timeline(methods map[string][]Invocation) []Invocation {
+	var resp []Invocation
+	for method, invocations := range methods {
+		for _, invocation := range invocations {
+			invocation.Method = method
+			resp = append(resp, invocation)
+		}
+	}
+	slices.SortFunc(resp, func(a, b Invocation) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	return resp
+}
+
+// goroutineID parses the current goroutine id from its stack trace.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	buf, _, _ = bytes.Cut(buf, []byte(" "))
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
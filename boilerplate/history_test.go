@@ -0,0 +1,41 @@
+package boilerplate
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Mock_Record(t *testing.T) {
+	login := NewMock[func(string) error](t, "UserDBMock", "Login")
+	logout := NewMock[func()](t, "UserDBMock", "Logout")
+
+	// record simulates the mock method, so the caller is this test.
+	record := func(m *Mock[func(string) error], args ...any) *Invocation { return m.Record(args...) }
+	invocation := record(login, "user")
+	logout.Record()
+	invocation.SetResults(nil)
+	record(login, "admin")
+
+	invocations := login.Invocations()
+	require.Len(t, invocations, 2)
+	require.Equal(t, []any{"user"}, invocations[0].Args)
+	require.Equal(t, []any{nil}, invocations[0].Results)
+	require.NotZero(t, invocations[0].Goroutine)
+	require.False(t, invocations[0].Time.IsZero())
+	require.True(t, strings.HasPrefix(filepath.Base(invocations[0].Caller), "history_test.go:"), invocations[0].Caller)
+	// Second call is still in progress.
+	require.Nil(t, invocations[1].Results)
+
+	timeline := Timeline(map[string][]Invocation{
+		"Login":  login.Invocations(),
+		"Logout": logout.Invocations(),
+	})
+	require.Len(t, timeline, 3)
+	require.Equal(t, "Login", timeline[0].Method)
+	require.Equal(t, "Logout", timeline[1].Method)
+	require.Equal(t, "Login", timeline[2].Method)
+	require.Equal(t, []any{"admin"}, timeline[2].Args)
+}
@@ -0,0 +1,57 @@
+package boilerplate
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// reportRow is a registered expectation, as shown in the failure table of AssertExpectations.
+type reportRow struct {
+	expectation, cardinality, calls, status, site string
+	met                                           bool
+}
+
+// reportRow returns the call as a row of the failure table, it must be called with the lock held.
+func (c *Call[T]) reportRow() reportRow {
+	row := reportRow{
+		expectation: c.describe(),
+		cardinality: c.cardinality(),
+		calls:       strconv.Itoa(c.calls),
+		status:      "ok",
+		site:        filepath.Base(c.site),
+		met:         true,
+	}
+	switch minCalls, maxCalls := c.min*len(c.hooks), c.max*len(c.hooks); {
+	case maxCalls >= 0 && c.calls > maxCalls:
+		row.status, row.met = fmt.Sprintf("%d too many", c.calls-maxCalls), false
+	case !c.maybe && c.calls < minCalls:
+		row.status, row.met = fmt.Sprintf("%d missing", minCalls-c.calls), false
+	}
+	return row
+}
+
+// printReport formats the expectations of the method with the given name as a table.
+func printReport(name string, rows []reportRow) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "expectations of %s were not met:\n", name)
+	w := tabwriter.NewWriter(b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\tEXPECTATION\tEXPECTED\tCALLS\tSTATUS\tREGISTERED AT\n")
+	for _, row := range rows {
+		fmt.Fprintf(w, "\t%s\t%s\t%s\t%s\t%s\n", row.expectation, row.cardinality, row.calls, row.status, row.site)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// callerSite returns the file:line location of the caller, skip is relative to the function calling callerSite.
+func callerSite(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
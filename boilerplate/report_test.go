@@ -0,0 +1,28 @@
+package boilerplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Mock_AssertExpectations_report(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(string) error](r, "UserDBMock", "Login")
+	// on simulates the generated On method, so the registration site is this test.
+	on := func(args ...any) Expectation[func(string) error] { return mock.Expect(args...) }
+	on("admin").AtLeast(2)
+	on("guest").Maybe()
+
+	_, ok := mock.Call("admin")
+	require.True(t, ok)
+	require.False(t, mock.AssertExpectations(r))
+	require.Equal(t, []string{"expectations of UserDBMock.Login were not met:\n" +
+		"  EXPECTATION                    EXPECTED    CALLS  STATUS     REGISTERED AT\n" +
+		"  UserDBMock.Login(Eq(\"admin\"))  at least 2  1      1 missing  report_test.go:14\n" +
+		"  UserDBMock.Login(Eq(\"guest\"))  at most 1   0      ok         report_test.go:15\n"}, r.errors)
+
+	_, ok = mock.Call("admin")
+	require.True(t, ok)
+	require.True(t, mock.AssertExpectations(r))
+}
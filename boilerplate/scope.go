@@ -0,0 +1,70 @@
+package boilerplate
+
+import (
+	"slices"
+)
+
+// Scope returns a mock sharing the deck and calls of c, in which new expectations belong to the test t.
+// Expectations registered on the scope are drawn before the ones from c, which are kept.
+// When t finishes, the scope expectations are asserted and removed from the deck, independently from c.
+// Scopes are meant for subtests running one at a time, since they share the calls to the mock.
+func (c *Mock[T]) Scope(t TestingT) *Mock[T] {
+	t.Helper()
+	root := c.root()
+	root.lock.Lock()
+	defer root.lock.Unlock()
+	scope := &Mock[T]{
+		t:      t,
+		name:   root.name,
+		parent: c,
+	}
+	root.scopes = append(root.scopes, scope)
+	t.Cleanup(func() {
+		t.Helper()
+		scope.AssertExpectations(t)
+		scope.Reset()
+		root.lock.Lock()
+		defer root.lock.Unlock()
+		root.scopes = slices.DeleteFunc(root.scopes, func(other *Mock[T]) bool { return other == scope })
+	})
+	return scope
+}
+
+// Reset removes the expectations registered on the mock and its scopes.
+// Resetting a mock which is not a scope also clears its history of calls.
+func (c *Mock[T]) Reset() {
+	root := c.root()
+	root.lock.Lock()
+	defer root.lock.Unlock()
+	root.calls = slices.DeleteFunc(root.calls, c.owns)
+	if c == root {
+		root.history = nil
+	}
+}
+
+// root returns the mock keeping the deck, c itself unless it's a scope.
+func (c *Mock[T]) root() *Mock[T] {
+	for c.parent != nil {
+		c = c.parent
+	}
+	return c
+}
+
+// depth returns how many scopes the mock is nested in.
+func (c *Mock[T]) depth() int {
+	var depth int
+	for ; c.parent != nil; c = c.parent {
+		depth++
+	}
+	return depth
+}
+
+// owns reports whether the call was registered on the mock or on one of its scopes.
+func (c *Mock[T]) owns(call *Call[T]) bool {
+	for owner := call.owner; owner != nil; owner = owner.parent {
+		if owner == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package boilerplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Mock_Scope(t *testing.T) {
+	parent := &reporter{}
+	mock := NewMock[func(string) int](parent, "CacheMock", "Get")
+	mock.SetFallback(FallbackFatal)
+	mock.Append(func(string) int { return 1 }).Repeat(RepeatForever)
+
+	sub := &reporter{}
+	scope := mock.Scope(sub)
+	scope.Expect("a").Do(func(string) int { return 2 })
+	scope.Expect("b")
+
+	// Scope expectations are drawn first, calls to the parent mock still reach them.
+	f, ok := mock.Call("a")
+	require.True(t, ok)
+	require.Equal(t, 2, (*f)("a"))
+	f, ok = scope.Call("c")
+	require.True(t, ok)
+	require.Equal(t, 1, (*f)("c"))
+
+	// Unexpected calls are reported to the running subtest.
+	scope.Expect("never").Never()
+	_, ok = mock.Call("never")
+	require.False(t, ok)
+	mock.Unexpected("Get", "never")
+	require.Len(t, sub.fatals, 1)
+	require.Empty(t, parent.fatals)
+
+	for _, cleanup := range sub.cleanups {
+		cleanup()
+	}
+	require.Len(t, sub.errors, 1)
+	require.Contains(t, sub.errors[0], "CacheMock.Get(Eq(\"b\"))")
+	// The parent expectations are not asserted by the scope.
+	require.NotContains(t, sub.errors[0], "at least 1")
+
+	// Scope expectations are removed once the subtest finishes.
+	f, ok = mock.Call("a")
+	require.True(t, ok)
+	require.Equal(t, 1, (*f)("a"))
+	require.True(t, mock.AssertExpectations(parent))
+	require.Empty(t, parent.errors)
+}
+
+func Test_Mock_Reset(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(string)](r, "CacheMock", "Get")
+	mock.Expect("a")
+	scope := mock.Scope(r)
+	scope.Expect("b")
+	mock.Record("a")
+
+	scope.Reset()
+	_, ok := mock.Call("b")
+	require.False(t, ok)
+	require.Len(t, mock.Invocations(), 1)
+
+	mock.Reset()
+	_, ok = mock.Call("a")
+	require.False(t, ok)
+	require.Empty(t, mock.Invocations())
+	require.True(t, mock.AssertExpectations(r))
+}
@@ -0,0 +1,82 @@
+package boilerplate
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type (
+	// Sequence asserts that calls to its configs happen in the order they were given,
+	// even across different methods and mocks.
+	Sequence struct {
+		lock     *sync.Mutex
+		t        TestingT
+		steps    []sequenceStep
+		cur      int
+		timeline []int
+	}
+
+	// sequenceStep is implemented by Call, so configs from mocks of any type can join a sequence.
+	sequenceStep interface {
+		join(s *Sequence, step int)
+		satisfied() bool
+		String() string
+	}
+)
+
+// InOrder creates a Sequence in which each config is expected to be called after the previous ones are satisfied.
+// A config is satisfied once it was called as many times as configured, or if it's set with Maybe.
+// Out of order calls fail the test with the timeline of calls from the sequence.
+//
+//	boilerplate.InOrder(t, mock.OnBegin(), mock.OnExec(), mock.OnCommit())
+func InOrder(t TestingT, configs ...Config) *Sequence {
+	t.Helper()
+	s := &Sequence{
+		lock: &sync.Mutex{},
+		t:    t,
+	}
+	for i, config := range configs {
+		step := config.asStep()
+		step.join(s, i)
+		s.steps = append(s.steps, step)
+	}
+	return s
+}
+
+// visit registers a call to the given step, failing the test if the call is out of order.
+func (s *Sequence) visit(step int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.timeline = append(s.timeline, step)
+	if step < s.cur {
+		s.t.Helper()
+		s.t.Errorf("call to %s is out of order, %s was already called\n%s", s.describe(step), s.describe(s.cur), s.printTimeline())
+		return
+	}
+	for i := s.cur; i < step; i++ {
+		if !s.steps[i].satisfied() {
+			s.t.Helper()
+			s.t.Errorf("call to %s is out of order, %s is not satisfied\n%s", s.describe(step), s.describe(i), s.printTimeline())
+			break
+		}
+	}
+	s.cur = step
+}
+
+func (s *Sequence) describe(step int) string {
+	return fmt.Sprintf("#%d %s", step+1, s.steps[step])
+}
+
+func (s *Sequence) printTimeline() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "expected order:\n")
+	for i := range s.steps {
+		fmt.Fprintf(b, "\t%s\n", s.describe(i))
+	}
+	fmt.Fprintf(b, "timeline:\n")
+	for i, step := range s.timeline {
+		fmt.Fprintf(b, "\t%d. %s\n", i+1, s.describe(step))
+	}
+	return b.String()
+}
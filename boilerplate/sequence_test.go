@@ -0,0 +1,124 @@
+package boilerplate
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_InOrder(t *testing.T) {
+	r := &reporter{}
+	begin := NewMock[func()](r, "DBMock", "Begin")
+	exec := NewMock[func(string) error](r, "DBMock", "Exec")
+	commit := NewMock[func() error](r, "DBMock", "Commit")
+
+	InOrder(r, begin.Append(), exec.Append(), commit.Append())
+
+	_, ok := begin.Call()
+	require.True(t, ok)
+	_, ok = exec.Call("query")
+	require.True(t, ok)
+	_, ok = commit.Call()
+	require.True(t, ok)
+	require.Empty(t, r.errors)
+}
+
+func Test_InOrder_outOfOrder(t *testing.T) {
+	r := &reporter{}
+	begin := NewMock[func()](r, "DBMock", "Begin")
+	exec := NewMock[func(string) error](r, "DBMock", "Exec")
+	commit := NewMock[func() error](r, "DBMock", "Commit")
+
+	InOrder(r, begin.Append(), exec.Append(), commit.Append())
+
+	begin.Call()
+	commit.Call()
+	require.Len(t, r.errors, 1)
+	require.Equal(t, "call to #3 DBMock.Commit is out of order, #2 DBMock.Exec is not satisfied\n"+
+		"expected order:\n"+
+		"\t#1 DBMock.Begin\n"+
+		"\t#2 DBMock.Exec\n"+
+		"\t#3 DBMock.Commit\n"+
+		"timeline:\n"+
+		"\t1. #1 DBMock.Begin\n"+
+		"\t2. #3 DBMock.Commit\n", r.errors[0])
+
+	exec.Call("query")
+	require.Len(t, r.errors, 2)
+	require.Contains(t, r.errors[1], "call to #2 DBMock.Exec is out of order, #3 DBMock.Commit was already called")
+}
+
+func Test_InOrder_repeatAndMaybe(t *testing.T) {
+	r := &reporter{}
+	begin := NewMock[func()](r, "DBMock", "Begin")
+	exec := NewMock[func(string) error](r, "DBMock", "Exec")
+	commit := NewMock[func() error](r, "DBMock", "Commit")
+
+	execConfig := exec.Append()
+	execConfig.Repeat(RepeatForever)
+	commitConfig := commit.Append()
+	commitConfig.Maybe()
+	InOrder(r, begin.Append(), execConfig, commitConfig)
+
+	begin.Call()
+	exec.Call("a")
+	exec.Call("b")
+	require.Empty(t, r.errors)
+}
+
+func Test_InOrder_concurrent(t *testing.T) {
+	const goroutines = 8
+	r := &reporter{}
+	exec := NewMock[func(string) error](r, "DBMock", "Exec")
+	commit := NewMock[func() error](r, "DBMock", "Commit")
+	// Steps from the same deck are visited without holding its lock.
+	first, second := exec.Expect("a"), exec.Expect("b")
+	first.Times(goroutines)
+	second.Times(goroutines)
+	InOrder(r, first, second, commit.Append())
+
+	var wg sync.WaitGroup
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exec.Call("a")
+		}()
+	}
+	wg.Wait()
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			exec.Call("b")
+		}()
+	}
+	wg.Wait()
+	commit.Call()
+	require.Empty(t, r.errors)
+}
+
+func Test_InOrder_joinConcurrent(t *testing.T) {
+	r := &reporter{}
+	exec := NewMock[func(string) error](r, "DBMock", "Exec")
+	config := exec.Expect("a")
+	config.Repeat(RepeatForever)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 100 {
+			exec.Call("a")
+			runtime.Gosched()
+		}
+	}()
+	// Configs join sequences while their mock is being called.
+	for range 100 {
+		InOrder(r, config)
+		runtime.Gosched()
+	}
+	<-done
+	require.Empty(t, r.errors)
+}
@@ -0,0 +1,147 @@
+package boilerplate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Waitable is implemented by Mock, so the calls from many methods of a mock can be waited together.
+type Waitable interface {
+	// received returns how many calls were received.
+	received() int
+	// pending describes the expectations that are still missing calls.
+	pending() []string
+}
+
+// changes is broadcast whenever a mock receives a call, waking up the goroutines waiting on mocks.
+// A single condition is shared by all mocks, so waiters can observe many of them at once.
+var changes = sync.NewCond(&sync.Mutex{})
+
+func notify() {
+	changes.L.Lock()
+	defer changes.L.Unlock()
+	changes.Broadcast()
+}
+
+// waitFor blocks until done returns true, or until ctx is done.
+// done is called with the changes lock held, so it must not notify.
+func waitFor(ctx context.Context, done func() bool) error {
+	stop := context.AfterFunc(ctx, notify)
+	defer stop()
+	changes.L.Lock()
+	defer changes.L.Unlock()
+	for !done() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		changes.Wait()
+	}
+	return nil
+}
+
+// WaitForCalls waits until the mocks receive n calls in total, or until ctx is done.
+func WaitForCalls(ctx context.Context, n int, mocks ...Waitable) error {
+	var received int
+	err := waitFor(ctx, func() bool {
+		received = 0
+		for _, mock := range mocks {
+			received += mock.received()
+		}
+		return received >= n
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for %d calls, received %d: %w", n, received, err)
+	}
+	return nil
+}
+
+// Eventually waits until all expectations registered on the mocks are met, or until ctx is done.
+// It's used when calls come from other goroutines, which could still be running when expectations are asserted.
+func Eventually(ctx context.Context, mocks ...Waitable) error {
+	var pending []string
+	err := waitFor(ctx, func() bool {
+		pending = pending[:0]
+		for _, mock := range mocks {
+			pending = append(pending, mock.pending()...)
+		}
+		return len(pending) == 0
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for expectations: %w, still pending:\n\t%s", err, strings.Join(pending, "\n\t"))
+	}
+	return nil
+}
+
+// WaitForCalls waits until the mock receives n calls, or until ctx is done.
+func (c *Mock[T]) WaitForCalls(ctx context.Context, n int) error {
+	return WaitForCalls(ctx, n, c)
+}
+
+// Eventually waits until all expectations registered on the mock are met, or until ctx is done.
+func (c *Mock[T]) Eventually(ctx context.Context) error {
+	return Eventually(ctx, c)
+}
+
+func (c *Mock[T]) received() int {
+	c = c.root()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.history)
+}
+
+func (c *Mock[T]) pending() []string {
+	root := c.root()
+	root.lock.Lock()
+	defer root.lock.Unlock()
+	var resp []string
+	for _, call := range root.calls {
+		if !c.owns(call) {
+			continue
+		}
+		if missing := call.missing(); missing > 0 {
+			resp = append(resp, fmt.Sprintf("%d more calls expected to %s", missing, call.describe()))
+		}
+	}
+	return resp
+}
+
+// Wait waits until the call is met, or until the timeout.
+func (c *Call[T]) Wait(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var missing int
+	err := waitFor(ctx, func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		missing = c.missing()
+		return missing == 0
+	})
+	if err != nil {
+		return fmt.Errorf("waiting %s: %w, %d more calls expected to %s", timeout, err, missing, c.describe())
+	}
+	return nil
+}
+
+// missing returns how many calls the call still requires, 0 if it's met.
+// It must be called with the lock held.
+func (c *Call[T]) missing() int {
+	if c.maybe {
+		return 0
+	}
+	return max(c.min*len(c.hooks)-c.calls, 0)
+}
+
+// describe returns the call name, and its argument matchers for expectations.
+func (c *Call[T]) describe() string {
+	if c.args == nil {
+		return c.String()
+	}
+	args := make([]string, 0, len(c.args))
+	for _, matcher := range c.args {
+		args = append(args, matcher.String())
+	}
+	return fmt.Sprintf("%s(%s)", c.String(), strings.Join(args, ", "))
+}
@@ -0,0 +1,33 @@
+package boilerplate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Mock_Wait(t *testing.T) {
+	r := &reporter{}
+	mock := NewMock[func(int)](r, "CacheMock", "Get")
+	first := mock.Expect(1)
+	mock.Expect(2)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		mock.Record(1)
+		mock.Call(1)
+	}()
+	require.NoError(t, first.Wait(time.Second))
+	require.NoError(t, mock.WaitForCalls(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := mock.Eventually(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorContains(t, err, "1 more calls expected to CacheMock.Get(Eq(2))")
+	require.ErrorContains(t, mock.WaitForCalls(ctx, 2), "waiting for 2 calls, received 1")
+
+	go mock.Call(2)
+	require.NoError(t, mock.Eventually(context.Background()))
+}
@@ -0,0 +1,98 @@
+package fake
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// commentedFile is a source file parsed with its comments, kept separately from the loaded packages.
+type commentedFile struct {
+	fileSet *token.FileSet
+	file    *ast.File
+}
+
+// parseComments parses the file with its comments, caching it for the other methods from the same file.
+// Methods can come from any package, including dependencies, so files are parsed on demand.
+func (g *Generator) parseComments(filename string) *commentedFile {
+	g.lock.Lock()
+	cached, ok := g.comments[filename]
+	g.lock.Unlock()
+	if ok {
+		return cached
+	}
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, filename, nil, parser.ParseComments)
+	if err != nil {
+		file = nil
+	}
+	cached = &commentedFile{fileSet: fileSet, file: file}
+	g.lock.Lock()
+	g.comments[filename] = cached
+	g.lock.Unlock()
+	return cached
+}
+
+// methodDoc returns the doc comment of an interface method, or an empty string if it has none.
+func (g *Generator) methodDoc(method *types.Func) string {
+	if method == nil {
+		return ""
+	}
+	position := g.FileSet.Position(method.Pos())
+	if !position.IsValid() {
+		return ""
+	}
+	cached := g.parseComments(position.Filename)
+	if cached.file == nil {
+		return ""
+	}
+	var doc *ast.CommentGroup
+	var found bool
+	ast.Inspect(cached.file, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok {
+			for _, name := range field.Names {
+				if cached.fileSet.Position(name.Pos()).Offset == position.Offset {
+					doc, found = field.Doc, true
+				}
+			}
+		}
+		return !found
+	})
+	return doc.Text()
+}
+
+// deprecation returns the paragraph of the doc starting with "Deprecated:", or an empty string.
+func deprecation(doc string) string {
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(paragraph, "Deprecated:") {
+			return strings.TrimSuffix(paragraph, "\n")
+		}
+	}
+	return ""
+}
+
+// writeDoc writes the text as a doc comment.
+func writeDoc(w io.Writer, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(doc, "\n"), "\n") {
+		if line == "" {
+			fmt.Fprintf(w, "//\n")
+			continue
+		}
+		fmt.Fprintf(w, "// %s\n", line)
+	}
+}
+
+// writeDeprecation copies the deprecation notice of the doc, so it's also reported on the helper methods.
+func writeDeprecation(w io.Writer, doc string) {
+	if notice := deprecation(doc); notice != "" {
+		fmt.Fprintf(w, "//\n")
+		writeDoc(w, notice)
+	}
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path"
+	"runtime"
+	"strings"
+	"syscall"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	mockgen "github.com/sonalys/fake"
+	"github.com/sonalys/fake/boilerplate"
+	"github.com/sonalys/fake/internal/config"
+	"github.com/sonalys/fake/internal/platform"
 )
 
 func init() {
@@ -36,24 +44,165 @@ func main() {
 	output := flag.String("output", "mocks", "Folder to output the generated mocks")
 	flag.Var(&ignore, "ignore", "Specify which folders should be ignored")
 	interfaceName = flag.String("interface", "", "If you want to generate a single interface on the same folder, specify using this flag")
-	pkgName = flag.String("mockPackage", "", "Usable with -interface only. Provide if you want a different package from the interface being generated")
-	flag.Parse()
+	pkgName = flag.String("mockPackage", "", "Provide if you want a different package name for the generated mocks")
+	fallbackName := flag.String("fallback", "panic", "Default behavior of mocks for unexpected calls: panic, fatal, error or zero")
+	importPath := flag.String("package", "", "Import path of a package outside the module to generate mocks for, like net/http. Use -interface to select a single interface")
+	configPath := flag.String("config", "", fmt.Sprintf("Configuration file, defaults to the %s file next to go.mod", config.Filename))
+	jobs := flag.Int("j", runtime.GOMAXPROCS(0), "Number of files generated concurrently")
+	tags := flag.String("tags", "", "Comma-separated list of build tags, files excluded by them are not mocked")
+	goos := flag.String("goos", "", "Operating system to evaluate build constraints for, defaults to $GOOS")
+	goarch := flag.String("goarch", "", "Architecture to evaluate build constraints for, defaults to $GOARCH")
+	check := flag.Bool("check", false, "Compare the generated mocks with the ones on disk, without writing them. Exits with 1 if any mock is stale")
+	// fake check is an alias for fake -check, fake watch keeps regenerating the mocks as files change.
+	var watch bool
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "check":
+			*check = true
+			args = args[1:]
+		case "watch":
+			watch = true
+			args = args[1:]
+		}
+	}
+	flag.CommandLine.Parse(args)
+	if watch && (*check || *interfaceName != "" || *importPath != "") {
+		log.Error().Msg("watch cannot be used with -check, -interface or -package")
+		os.Exit(1)
+	}
+	fallback, err := boilerplate.ParseFallback(*fallbackName)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid -fallback flag")
+		os.Exit(1)
+	}
+	cfg, err := loadConfig(*configPath, input)
+	if err != nil {
+		log.Error().Err(err).Msg("invalid configuration file")
+		os.Exit(1)
+	}
+	// Flags override the configuration file.
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if cfg != nil {
+		// Inputs from the configuration file are not used by go:generate directives, which generate their own folder.
+		if !setFlags["input"] && *interfaceName == "" {
+			for _, entry := range cfg.Inputs {
+				input = append(input, cfg.Path(entry))
+			}
+		}
+		if !setFlags["ignore"] {
+			for _, entry := range cfg.Ignore {
+				ignore = append(ignore, cfg.Path(entry))
+			}
+		}
+		if !setFlags["output"] && cfg.Output != "" {
+			*output = cfg.Path(cfg.Output)
+		}
+		if !setFlags["mockPackage"] && cfg.MockPackage != "" {
+			*pkgName = cfg.MockPackage
+		}
+		if !setFlags["fallback"] && cfg.Fallback != nil {
+			fallback = *cfg.Fallback
+		}
+	}
+	build := platform.Settings{GOOS: *goos, GOARCH: *goarch}
+	if *tags != "" {
+		build.Tags = strings.Split(*tags, ",")
+	}
+	var externals []config.External
+	if cfg != nil {
+		externals = cfg.External
+	}
 	if len(input) == 0 {
 		// Defaults to $CWD
 		input = []string{"."}
 	}
+	if *importPath != "" {
+		external := config.External{Package: *importPath}
+		if *interfaceName != "" {
+			external.Interfaces = []string{*interfaceName}
+		}
+		run(*check, mockgen.RunConfig{
+			Inputs:       input,
+			Output:       *output,
+			MockPackage:  *pkgName,
+			Fallback:     fallback,
+			External:     []config.External{external},
+			ExternalOnly: true,
+			Jobs:         *jobs,
+			Config:       cfg,
+			Build:        build,
+		})
+		return
+	}
 	if *interfaceName != "" {
-		if *output != "mocks" {
+		if *check {
+			log.Error().Msg("-check cannot be used when -interface is set")
+			os.Exit(1)
+		}
+		if setFlags["output"] {
 			log.Error().Msgf("-output %s cannot be used when -interface is set", *output)
-			return
+			os.Exit(1)
 		}
 		mockgen.GenerateInterface(mockgen.GenerateInterfaceConfig{
 			PackageName:   *pkgName,
 			Inputs:        input,
 			InterfaceName: *interfaceName,
 			OutputFolder:  path.Dir(input[0]),
+			Fallback:      fallback,
+			Config:        cfg,
+			Build:         build,
 		})
 		return
 	}
-	mockgen.Run(input, *output, ignore)
+	c := mockgen.RunConfig{
+		Inputs:      input,
+		Output:      *output,
+		Ignore:      ignore,
+		MockPackage: *pkgName,
+		Fallback:    fallback,
+		External:    externals,
+		Jobs:        *jobs,
+		Config:      cfg,
+		Build:       build,
+	}
+	if watch {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := mockgen.Watch(ctx, c); err != nil {
+			log.Fatal().Err(err).Msg("error watching files")
+		}
+		return
+	}
+	run(*check, c)
+}
+
+// run generates the mocks, or only compares them with the ones on disk when check is set.
+func run(check bool, c mockgen.RunConfig) {
+	if !check {
+		mockgen.Run(c)
+		return
+	}
+	stale, err := mockgen.Check(c, os.Stdout)
+	if err != nil {
+		log.Fatal().Err(err).Msg("error checking mocks")
+	}
+	if stale > 0 {
+		log.Error().Msgf("%d stale files, run fake to regenerate them", stale)
+		os.Exit(1)
+	}
+	log.Info().Msg("mocks are up to date")
+}
+
+// loadConfig loads the configuration file from configPath, or discovers it next to the go.mod of the first input.
+func loadConfig(configPath string, input []string) (*config.Config, error) {
+	if configPath != "" {
+		return config.Load(configPath)
+	}
+	dir := "."
+	if len(input) > 0 {
+		dir = input[0]
+	}
+	return config.Find(dir)
 }
@@ -2,42 +2,126 @@ package fake
 
 import (
 	"fmt"
-	"go/ast"
-	"io"
-	"strings"
+	"go/types"
+	"slices"
 )
 
 type ParsedField struct {
 	Interface *ParsedInterface
-	Ref       *ast.Field
+	Ref       *types.Func
+	Signature *types.Signature
 	Name      string
-	Type      string
+
+	paramNames, resultNames []string
+}
+
+// reservedNames are identifiers used by the generated methods, which parameters can't shadow.
+var reservedNames = []string{"s", "c", "f", "ok", "invocation", "ctxErr"}
+
+// displayName returns how the field is called in comments and failures.
+// Function types have a single field without name, so the type name is used.
+func (f *ParsedField) displayName() string {
+	if f.Name == "" {
+		return f.Interface.Name
+	}
+	return f.Name
+}
+
+// HasContext reports whether the first parameter is a context.Context, so calls can wait until it's done.
+func (f *ParsedField) HasContext() bool {
+	params := f.Signature.Params()
+	if params.Len() == 0 {
+		return false
+	}
+	named, ok := types.Unalias(params.At(0).Type()).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// doc returns the doc comment of the method in the source interface.
+func (f *ParsedField) doc() string {
+	return f.Interface.ParsedFile.Generator.methodDoc(f.Ref)
+}
+
+// names resolves the name of each parameter and result, keeping the names from the source when possible.
+// Unnamed, blank or colliding names, like the ones shadowing packages, fall back to a0, a1... and r0, r1...
+func (f *ParsedField) names() ([]string, []string) {
+	if f.paramNames != nil {
+		return f.paramNames, f.resultNames
+	}
+	// Printing the types reserves the aliases of all packages used by the signature.
+	f.ParamTypes()
+	f.ResultTypes()
+	used := make(map[string]bool)
+	for _, name := range append(reservedNames, f.Interface.GenericsNames...) {
+		used[name] = true
+	}
+	isValid := func(name string) bool {
+		return name != "" && name != "_" && !used[name] &&
+			!f.Interface.ParsedFile.Imports.Has(name) && types.Universe.Lookup(name) == nil
+	}
+	resolve := func(tuple *types.Tuple, prefix string) []string {
+		resp := make([]string, 0, tuple.Len())
+		for i := range tuple.Len() {
+			name := tuple.At(i).Name()
+			for n := i; !isValid(name); n++ {
+				name = fmt.Sprintf("%s%d", prefix, n)
+			}
+			used[name] = true
+			resp = append(resp, name)
+		}
+		return resp
+	}
+	f.paramNames = resolve(f.Signature.Params(), "a")
+	f.resultNames = resolve(f.Signature.Results(), "r")
+	return f.paramNames, f.resultNames
+}
+
+// ParamNames returns the name of each parameter.
+func (f *ParsedField) ParamNames() []string {
+	params, _ := f.names()
+	return slices.Clone(params)
+}
+
+// ResultNames returns the name of each result, even if results are unnamed in the source.
+func (f *ParsedField) ResultNames() []string {
+	_, results := f.names()
+	return slices.Clone(results)
 }
 
-func getFieldName(i int) string {
-	return fmt.Sprintf("a%d", i)
+// NamedResults reports whether the results are named in the source, and so in the generated signatures.
+func (f *ParsedField) NamedResults() bool {
+	results := f.Signature.Results()
+	return results.Len() > 0 && results.At(0).Name() != ""
 }
 
-func getFieldCallingName(i int, field *ast.Field) string {
-	switch field.Type.(type) {
-	case *ast.Ellipsis:
-		return fmt.Sprintf("%s...", getFieldName(i))
+// CallingNames returns the name of each parameter, as used for calling the method.
+func (f *ParsedField) CallingNames() []string {
+	resp := f.ParamNames()
+	if f.Signature.Variadic() {
+		resp[len(resp)-1] += "..."
 	}
-	return getFieldName(i)
+	return resp
 }
 
-func (f *ParsedInterface) PrintAstField(i int, field *ast.Field, printName bool) string {
-	typeName := f.printAstExpr(field.Type)
-	if printName {
-		return fmt.Sprintf("%s %s", getFieldName(i), typeName)
+// ParamTypes returns the type of each parameter, variadic parameters are returned as slices.
+func (f *ParsedField) ParamTypes() []string {
+	params := f.Signature.Params()
+	resp := make([]string, 0, params.Len())
+	for i := range params.Len() {
+		resp = append(resp, f.Interface.ParsedFile.printType(params.At(i).Type()))
 	}
-	return typeName
+	return resp
 }
 
-func (f *ParsedInterface) PrintAstFields(implFile io.Writer, fields []*ast.Field, printName bool) {
-	var buffer []string
-	for i, field := range fields {
-		buffer = append(buffer, f.PrintAstField(i, field, printName))
+// ResultTypes returns the type of each result.
+func (f *ParsedField) ResultTypes() []string {
+	results := f.Signature.Results()
+	resp := make([]string, 0, results.Len())
+	for i := range results.Len() {
+		resp = append(resp, f.Interface.ParsedFile.printType(results.At(i).Type()))
 	}
-	fmt.Fprint(implFile, strings.Join(buffer, ", "))
+	return resp
 }
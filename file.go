@@ -4,26 +4,24 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"io"
 	"slices"
 
-	"github.com/rs/zerolog/log"
+	"github.com/sonalys/fake/internal/config"
 	"github.com/sonalys/fake/internal/imports"
 )
 
 type ParsedFile struct {
-	Generator       *Generator
-	Size            int
-	Ref             *ast.File
-	PkgPath         string
-	PkgName         string
-	Imports         map[string]*imports.ImportEntry
-	OriginalImports map[string]*imports.ImportEntry
-	ImportsPathMap  map[string]*imports.ImportEntry
-	UsedImports     map[string]struct{}
-
-	importResolved bool
-	importAlias    string
+	Generator *Generator
+	Ref       *ast.File
+	Package   *types.Package
+	PkgPath   string
+	PkgName   string
+	// Imports resolves the packages used by the generated file.
+	Imports *imports.Set
+	// Config holds the overrides for the file package.
+	Config config.Package
 }
 
 func (f *ParsedFile) ListInterfaces(names ...string) []*ParsedInterface {
@@ -39,36 +37,26 @@ func (f *ParsedFile) ListInterfaces(names ...string) []*ParsedInterface {
 			if !ok || len(names) > 0 && !slices.Contains(names, typeSpec.Name.Name) {
 				continue
 			}
-			interfaceType, ok := typeSpec.Type.(*ast.InterfaceType)
-			if !ok {
-				continue
-			}
-			cur := &ParsedInterface{
-				ParsedFile: f,
-				Type:       typeSpec,
-				Ref:        interfaceType,
-				Name:       typeSpec.Name.Name,
+			if cur := f.parseInterface(typeSpec.Name.Name); cur != nil {
+				resp = append(resp, cur)
 			}
-			cur.GenericsTypes, cur.GenericsNames = cur.getGenericsInfo()
-			resp = append(resp, cur)
 		}
 	}
 	return resp
 }
 
+// printType prints the type as used from the generated file, qualifying it with the imported package aliases.
+func (f *ParsedFile) printType(t types.Type) string {
+	return types.TypeString(t, f.Imports.Qualifier)
+}
+
 func (f *ParsedFile) writeImports(w io.Writer) {
 	// Write import statements
 	fmt.Fprintf(w, "import (\n")
-	fmt.Fprintf(w, "\t\"fmt\"\n")
-	fmt.Fprintf(w, "\t\"testing\"\n")
 	fmt.Fprintf(w, "\tmockSetup \"github.com/sonalys/fake/boilerplate\"\n")
-	for name := range f.UsedImports {
-		info, ok := f.Imports[name]
-		if !ok {
-			log.Fatal().Msg("inconsistency between usedImports and imports state")
-		}
+	for _, info := range f.Imports.Used() {
 		fmt.Fprintf(w, "\t")
-		if info.Alias != "" && info.Alias != info.PackageInfo.Name {
+		if info.Alias != info.Name {
 			fmt.Fprintf(w, "%s ", info.Alias)
 		}
 		fmt.Fprintf(w, "\"%s\"\n", info.Path)
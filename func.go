@@ -0,0 +1,34 @@
+package fake
+
+import (
+	"fmt"
+	"io"
+)
+
+// writeFuncMock writes the mock of a named function type.
+// The function has a single deck, configured with On and Expect, and is converted to the original type with Func.
+func (i *ParsedInterface) writeFuncMock(w io.Writer) {
+	field := i.ListFields()[0]
+	i.writeStruct(w)
+	i.writeInitializer(w)
+	i.writeAssertExpectations(w)
+	i.writeScope(w)
+	i.writeWait(w)
+	i.writeMethodFallback(w, field)
+	i.writeConfig(w, field.Name, field)
+	i.writeOnMethod(w, field.Name, field)
+	i.writeExpectMethod(w, field.Name, field)
+	i.writeFuncValue(w, field)
+	i.writeCallHistory(w, field.Name, field)
+}
+
+func (i *ParsedInterface) writeFuncValue(w io.Writer, f *ParsedField) {
+	fmt.Fprintf(w, "// Func returns the mock as a %s function.\n", i.Name)
+	fmt.Fprintf(w, "func (s *%s%s) Func() %s {\n", i.getMockName(), i.writeGenericsNameHeader(), i.getInterfaceType())
+	fmt.Fprintf(w, "\treturn ")
+	i.PrintMethodHeader(w, "func", f)
+	fmt.Fprintf(w, "{\n")
+	i.writeMethodBody(w, f.Name, f)
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "}\n\n")
+}
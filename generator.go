@@ -1,24 +1,38 @@
 package fake
 
 import (
-	"go/ast"
 	"go/token"
 	"os"
-	"path"
+	"sync"
 
+	"github.com/sonalys/fake/boilerplate"
+	"github.com/sonalys/fake/internal/config"
 	"github.com/sonalys/fake/internal/files"
-	"github.com/sonalys/fake/internal/imports"
+	"github.com/sonalys/fake/internal/platform"
 	"golang.org/x/mod/modfile"
 )
 
 // Generator is the controller for the whole module, caching files and holding metadata.
+// It's safe for concurrent use, as long as its exported fields are not modified while generating.
 type Generator struct {
 	FileSet         *token.FileSet
 	MockPackageName string
+	// Fallback is the default behavior of generated mocks for unexpected calls.
+	Fallback boilerplate.Fallback
+	// Config holds the project overrides for packages and interfaces, it can be nil.
+	Config *config.Config
+	// Build selects the files and platform used to load packages.
+	Build platform.Settings
+	// InPackage is set when mocks are written to the package of their interfaces, which is then not imported.
+	InPackage bool
 
-	cachedPackageInfo func(f *ast.File) (nameMap, pathMap map[string]*imports.ImportEntry)
-	goModFilename     string
-	goMod             *modfile.File
+	lock sync.Mutex
+	// packages caches type-checked packages.
+	packages map[packageKey]*loadedPackage
+	// comments caches the files parsed for doc comments.
+	comments      map[string]*commentedFile
+	goModFilename string
+	goMod         *modfile.File
 }
 
 // NewGenerator will create a new mock generator for the specified module.
@@ -39,10 +53,11 @@ func NewGenerator(pkgName, baseDir string) (*Generator, error) {
 	}
 
 	return &Generator{
-		FileSet:           token.NewFileSet(),
-		goModFilename:     goModPath,
-		goMod:             modFile,
-		MockPackageName:   pkgName,
-		cachedPackageInfo: imports.CachedImportInformation(path.Dir(goModPath)),
+		FileSet:         token.NewFileSet(),
+		goModFilename:   goModPath,
+		goMod:           modFile,
+		MockPackageName: pkgName,
+		packages:        make(map[packageKey]*loadedPackage),
+		comments:        make(map[string]*commentedFile),
 	}, nil
 }
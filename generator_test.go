@@ -1,20 +1,372 @@
 package fake
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
 	"testing"
 
+	"github.com/sonalys/fake/boilerplate"
+	"github.com/sonalys/fake/internal/config"
+	"github.com/sonalys/fake/internal/platform"
 	"github.com/stretchr/testify/require"
 )
 
 func Test_Generate(t *testing.T) {
-	// output := t.TempDir()
-	output := "out"
-	os.RemoveAll(output) // no caching
-	Run([]string{"testdata"}, output, nil)
+	dir := testModule(t)
+	output := filepath.Join(dir, "mocks")
+	Run(RunConfig{
+		Inputs:   []string{"testdata"},
+		Output:   output,
+		External: []config.External{{Package: "net/http", Interfaces: []string{"RoundTripper"}}},
+	})
 	g, err := NewGenerator("mocks", "testdata")
 	require.NoError(t, err)
 	_, err = g.ParseFile(path.Join(output, "testdata", "stub.gen.go"))
 	require.NoError(t, err)
+	// Generated mocks should compile.
+	runGo(t, dir, "build", "./...")
+}
+
+// testModule creates a module on a temporary folder, using this module from the working tree.
+func testModule(t *testing.T) string {
+	t.Helper()
+	// Requirements of this module are resolved from its go.mod, instead of being listed by the test module.
+	t.Setenv("GOFLAGS", "-mod=mod")
+	dir := t.TempDir()
+	root, err := filepath.Abs(".")
+	require.NoError(t, err)
+	goSum, err := os.ReadFile("go.sum")
+	require.NoError(t, err)
+	goMod := fmt.Sprintf("module example.com/mocks\n\ngo 1.23.0\n\nrequire github.com/sonalys/fake v0.0.0\n\nreplace github.com/sonalys/fake => %s\n", root)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o644))
+	return dir
+}
+
+// runGo runs the go command on dir, failing the test with its output.
+func runGo(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func Test_Generate_Config(t *testing.T) {
+	g, err := NewGenerator("", "testdata")
+	require.NoError(t, err)
+	dir, err := filepath.Abs(".")
+	require.NoError(t, err)
+	spy, fallback := false, boilerplate.FallbackFatal
+	g.Config = &config.Config{
+		Dir: dir,
+		Packages: map[string]config.Package{
+			"testdata": {
+				MockPackage: "stubmock",
+				Interfaces: map[string]config.Interface{
+					"Reader":           {MockName: "FakeReader", Fallback: &fallback, Spy: &spy},
+					"AnotherInterface": {Skip: true},
+				},
+			},
+		},
+	}
+	b := string(g.GenerateFile("testdata/stub.go"))
+	require.Contains(t, b, "package stubmock")
+	require.Contains(t, b, "type FakeReader struct")
+	require.Contains(t, b, "s.SetFallback(mockSetup.FallbackFatal)")
+	require.NotContains(t, b, "NewReaderSpy")
+	require.Contains(t, b, "NewStubInterfaceSpy")
+	require.NotContains(t, b, "AnotherInterfaceMock")
+
+	generated, err := g.GeneratePackage("io", "Reader")
+	require.NoError(t, err)
+	require.Len(t, generated, 1)
+	for _, b := range generated {
+		require.Contains(t, string(b), "type ReaderMock struct")
+	}
+}
+
+func Test_Generate_Spy(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	output := testModule(t)
+	require.NoError(t, os.WriteFile(filepath.Join(output, "stub.gen.go"), g.GenerateFile("testdata/stub.go", "Documented"), 0o644))
+	// Expectations without functions delegate to the real implementation.
+	require.NoError(t, os.WriteFile(filepath.Join(output, "spy_test.go"), []byte(`package mocks
+
+import (
+	"testing"
+
+	"github.com/sonalys/fake/matchers"
+)
+
+type store map[string]string
+
+func (s store) Get(key string) string { return s[key] }
+
+func (s store) Lookup(key string) (string, bool) {
+	value, ok := s[key]
+	return value, ok
+}
+
+func Test_Spy(t *testing.T) {
+	mock := NewDocumentedSpy(t, store{"a": "real"})
+	mock.ExpectLookup(matchers.Eq("a"))
+	mock.OnLookup().Return("hooked", true)
+	if value, ok := mock.Lookup("a"); value != "real" || !ok {
+		t.Fatalf("expected the real value, got %q %v", value, ok)
+	}
+	if value, _ := mock.Lookup("b"); value != "hooked" {
+		t.Fatalf("expected the hooked value, got %q", value)
+	}
+}
+`), 0o644))
+	runGo(t, output, "test", ".")
+}
+
+func Test_GenerateInterface_InPackage(t *testing.T) {
+	dir := testModule(t)
+	pkgDir := filepath.Join(dir, "store")
+	require.NoError(t, os.MkdirAll(pkgDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(pkgDir, "store.go"), []byte("package store\n\ntype Key string\n\ntype Store interface {\n\tGet(key Key) string\n}\n"), 0o644))
+
+	GenerateInterface(GenerateInterfaceConfig{
+		Inputs:        []string{pkgDir},
+		InterfaceName: "Store",
+		OutputFolder:  pkgDir,
+	})
+	b, err := os.ReadFile(filepath.Join(pkgDir, "store.Store.gen.go"))
+	require.NoError(t, err)
+	// Types from the package of the interface are not imported, as it would be an import cycle.
+	require.Contains(t, string(b), "func NewStoreSpy(t mockSetup.TestingT, real Store) *StoreMock {")
+	require.Contains(t, string(b), "func (s *StoreMock) Get(key Key) string {")
+	runGo(t, dir, "vet", "./...")
+}
+
+func Test_Generate_SpyUnexported(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	b := string(g.GenerateFile("testdata/stub.go", "Unexported"))
+	require.Contains(t, b, "func (s *UnexportedMock) mockSetup(x int) int {")
+	require.NotContains(t, b, "NewUnexportedSpy")
+	require.NotContains(t, b, "s.real")
+}
+
+func Test_Check(t *testing.T) {
+	c := RunConfig{Inputs: []string{"testdata"}, Output: t.TempDir()}
+	Run(c)
+	var diff bytes.Buffer
+	stale, err := Check(c, &diff)
+	require.NoError(t, err)
+	require.Zero(t, stale, diff.String())
+
+	filename := path.Join(c.Output, "testdata", "stub.gen.go")
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	edited := bytes.Replace(content, []byte("func NewReaderMock("), []byte("func NewEditedMock("), 1)
+	require.NoError(t, os.WriteFile(filename, edited, 0o644))
+
+	stale, err = Check(c, &diff)
+	require.NoError(t, err)
+	require.Equal(t, 1, stale)
+	require.Contains(t, diff.String(), "-func NewEditedMock(")
+	require.Contains(t, diff.String(), "+func NewReaderMock(")
+	// Check never touches the filesystem.
+	current, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, edited, current)
+}
+
+func Test_Check_RemovedInterfaces(t *testing.T) {
+	dir := testModule(t)
+	source := filepath.Join(dir, "store", "store.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(source), 0o755))
+	require.NoError(t, os.WriteFile(source, []byte("package store\n\ntype Store interface {\n\tGet(key string) string\n}\n"), 0o644))
+	c := RunConfig{Inputs: []string{dir}, Output: filepath.Join(dir, "mocks")}
+	Run(c)
+	filename := filepath.Join(c.Output, "store", "store.gen.go")
+	require.FileExists(t, filename)
+
+	// Mocks from files without interfaces are stale.
+	require.NoError(t, os.WriteFile(source, []byte("package store\n\ntype Store struct{}\n"), 0o644))
+	var diff bytes.Buffer
+	stale, err := Check(c, &diff)
+	require.NoError(t, err)
+	require.Positive(t, stale)
+	require.Contains(t, diff.String(), "--- "+filename+"\n+++ /dev/null")
+	require.FileExists(t, filename)
+
+	Run(c)
+	require.NoFileExists(t, filename)
+}
+
+func Test_Generator_Concurrent(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	inputs := []string{"testdata/stub.go", "testdata/anotherpkg/stub2.go", "testdata/stub.go", "testdata/anotherpkg/stub2.go"}
+	require.NoError(t, g.LoadPackages(inputs...))
+	results := make([][]byte, len(inputs))
+	forEach(len(inputs), []int{0, 1, 2, 3}, func(i int) {
+		results[i] = g.GenerateFile(inputs[i])
+	})
+	require.NotEmpty(t, results[0])
+	require.Equal(t, results[0], results[2])
+	require.Equal(t, results[1], results[3])
+}
+
+func Test_Generate_ParamNames(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	b := string(g.GenerateFile("testdata/stub.go", "NamedParams"))
+	// Blank names and names shadowing imports, predeclared identifiers or generated variables fall back to positional names.
+	require.Contains(t, b, "func (s *NamedParamsMock) Grouped(a int, b int, a2 string, a3 time.Duration, a4 string, T bool) (n int, err error) {")
+	require.Contains(t, b, "func (s *NamedParamsMock) Blank(context string, a1 int) (r0 int, r1 bool) {")
+	require.Contains(t, b, "func (s *NamedParamsMock) OnGrouped(funcs ...func(a int, b int, a2 string, a3 time.Duration, a4 string, T bool) (n int, err error)) NamedParamsMockGroupedConfig {")
+}
+
+func Test_Generate_Scope(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	b := string(g.GenerateFile("testdata/stub.go", "Reader"))
+	require.Contains(t, b, "func (s *ReaderMock) Reset() {\n\ts.setupRead.Reset()\n}")
+	require.Contains(t, b, "func (s *ReaderMock) Scope(t mockSetup.TestingT) *ReaderMock {\n\tt.Helper()\n\treturn &ReaderMock{\n\t\treal:      s.real,\n\t\tsetupRead: s.setupRead.Scope(t),\n\t}\n}")
+	// Helpers named like the interface methods are not generated.
+	b = string(g.GenerateFile("testdata/stub.go", "Buffer"))
+	require.Contains(t, b, "func (s *BufferMock) Reset() {\n\tinvocation :=")
+	require.Contains(t, b, "func (s *BufferMock) Scope(")
+}
+
+func Test_Generate_NameCollisions(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	// Accessors named like the interface methods are not generated.
+	b := string(g.GenerateFile("testdata/stub.go", "Recorder"))
+	require.Contains(t, b, "func (s *RecorderMock) Calls() []string {")
+	require.NotContains(t, b, "func (s *RecorderMock) Calls() []mockSetup.Invocation {")
+	require.Contains(t, b, "func (s *RecorderMock) Eventually(timeout time.Duration) bool {")
+	require.NotContains(t, b, "func (s *RecorderMock) Eventually(ctx context.Context) error {")
+	require.Contains(t, b, "func (s *RecorderMock) WaitForCalls(ctx context.Context, n int) error {")
+	b = string(g.GenerateFile("testdata/stub.go", "Pair"))
+	require.Contains(t, b, "func (s *PairMock) GetCalls() int {")
+	require.NotContains(t, b, "func (s *PairMock) GetCalls() []PairMockGetCall {")
+	require.Contains(t, b, "func (s *PairMock) GetCallsCalls() []PairMockGetCallsCall {")
+
+	g.Fallback = boilerplate.FallbackFatal
+	b = string(g.GenerateFile("testdata/stub.go", "Fallbacks"))
+	require.Contains(t, b, "func (s *FallbacksMock) SetFallback(name string) {")
+	require.NotContains(t, b, "func (s *FallbacksMock) SetFallback(fallback mockSetup.Fallback) {")
+	require.NotContains(t, b, "func (s *FallbacksMock) SetGetFallback(fallback mockSetup.Fallback) {")
+	require.Contains(t, b, "func (s *FallbacksMock) SetSetFallbackFallback(fallback mockSetup.Fallback) {")
+	// The initializer sets the fallback of each method instead.
+	require.Contains(t, b, "\ts.setupGet.SetFallback(mockSetup.FallbackFatal)\n")
+}
+
+func Test_Generate_Docs(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	b := string(g.GenerateFile("testdata/stub.go", "Documented"))
+	require.Contains(t, b, "// Lookup returns the value stored for key,\n// and whether it was found.\nfunc (s *DocumentedMock) Lookup(")
+	require.Contains(t, b, "// Get returns the value stored for key.\n//\n// Deprecated: use Lookup instead.\nfunc (s *DocumentedMock) Get(")
+	require.Contains(t, b, "//\n// Deprecated: use Lookup instead.\nfunc (s *DocumentedMock) OnGet(")
+	require.Contains(t, b, "//\n// Deprecated: use Lookup instead.\nfunc (s *DocumentedMock) ExpectGet(")
+	require.NotContains(t, b, "Deprecated: use Lookup instead.\nfunc (s *DocumentedMock) OnLookup(")
+
+	doc, err := g.GenerateDoc("testdata", ".")
+	require.NoError(t, err)
+	require.Contains(t, string(doc), "// Package mocks contains the mocks generated by fake from github.com/sonalys/fake/testdata:\n")
+	require.Contains(t, string(doc), "//   - Documented\n")
+	require.Contains(t, string(doc), "\npackage mocks\n")
+}
+
+func Test_Generate_BuildConstraints(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	// Files excluded by the build settings are skipped.
+	require.Empty(t, g.GenerateFile("testdata/tagged_windows.go"))
+
+	g, err = NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	g.Build = platform.Settings{Tags: []string{"faketag"}, GOOS: "windows"}
+	b := string(g.GenerateFile("testdata/tagged_windows.go"))
+	require.Contains(t, b, "//go:build faketag && windows\n\npackage mocks")
+	require.Contains(t, b, "type TaggedMock struct")
+	require.NotContains(t, string(g.GenerateFile("testdata/stub.go")), "//go:build")
+}
+
+func Test_Generate_Workspace(t *testing.T) {
+	// Workspaces don't accept -mod=mod, which can be set by the environment.
+	t.Setenv("GOFLAGS", "")
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	writeFile("go.work", "go 1.23\n\nuse (\n\t./services/api\n\t./services/worker\n)\n")
+	writeFile("services/api/go.mod", "module example.com/api\n\ngo 1.23\n")
+	writeFile("services/api/users/users.go", "package users\n\ntype Repository interface {\n\tGet(id int) string\n}\n")
+	writeFile("services/worker/go.mod", "module example.com/worker\n\ngo 1.23\n")
+	writeFile("services/worker/users/jobs.go", "package users\n\nimport \"example.com/api/users\"\n\ntype Queue interface {\n\tPush(users.Repository)\n}\n")
+
+	// The workspace is found from its subfolders.
+	output := filepath.Join(dir, "services", "api", "mocks")
+	Run(RunConfig{Inputs: []string{filepath.Join(dir, "services")}, Output: output})
+	// Mocks are written inside their own module, each module with its own lock file.
+	require.FileExists(t, filepath.Join(output, "users", "users.gen.go"))
+	require.FileExists(t, filepath.Join(output, "fake.lock.json"))
+	require.NoDirExists(t, filepath.Join(output, "example.com"))
+	workerOutput := filepath.Join(dir, "services", "worker", "mocks")
+	b, err := os.ReadFile(filepath.Join(workerOutput, "users", "jobs.gen.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(b), "func (s *QueueMock) Push(a0 users.Repository)")
+	require.FileExists(t, filepath.Join(workerOutput, "fake.lock.json"))
+
+	var diff bytes.Buffer
+	stale, err := Check(RunConfig{Inputs: []string{dir}, Output: output}, &diff)
+	require.NoError(t, err)
+	require.Zero(t, stale, diff.String())
+}
+
+func Test_Generate_Context(t *testing.T) {
+	g, err := NewGenerator("mocks", "testdata")
+	require.NoError(t, err)
+	b := string(g.GenerateFile("testdata/fetcher.go"))
+	require.Contains(t, b, "f, ok, ctxErr := s.setupFetch.CallContext(ctx, ctx, key)")
+	require.Contains(t, b, "case ctxErr != nil:\n\t\t// Delayed calls return the context error when it's done first.\n\t\tr1 = ctxErr\n")
+	require.Contains(t, b, "func (c FetcherMockFetchConfig) Block() FetcherMockFetchConfig {")
+	require.Contains(t, b, "func (c FetcherMockNotifyConfig) Delay(d time.Duration) FetcherMockNotifyConfig {")
+	// Methods without context are not delayed.
+	b = string(g.GenerateFile("testdata/stub.go", "Reader"))
+	require.NotContains(t, b, "CallContext")
+	require.NotContains(t, b, "Block()")
+}
+
+func Test_Generator_invalidate(t *testing.T) {
+	dir := testModule(t)
+	writeFile := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	writeFile("base/base.go", "package base\n\ntype Base interface {\n\tGet() int\n}\n")
+	writeFile("store/store.go", "package store\n\nimport \"example.com/mocks/base\"\n\ntype Store interface {\n\tbase.Base\n}\n")
+	g, err := NewGenerator("mocks", dir)
+	require.NoError(t, err)
+	require.Contains(t, string(g.GenerateFile(filepath.Join(dir, "store", "store.go"))), "func (s *StoreMock) Get() int {")
+
+	// Dependencies are loaded from their export data, which is refreshed once they are invalidated.
+	writeFile("base/base.go", "package base\n\ntype Base interface {\n\tGet() int\n\tSet(int)\n}\n")
+	g.invalidate(filepath.Join(dir, "base"))
+	require.Contains(t, string(g.GenerateFile(filepath.Join(dir, "store", "store.go"))), "func (s *StoreMock) Set(a0 int) {")
+}
+
+// Benchmark_GenerateFile measures cold runs, loading the package from scratch on each iteration.
+func Benchmark_GenerateFile(b *testing.B) {
+	for range b.N {
+		g, err := NewGenerator("mocks", "testdata")
+		require.NoError(b, err)
+		require.NotEmpty(b, g.GenerateFile("testdata/stub.go"))
+	}
 }
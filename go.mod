@@ -1,19 +1,21 @@
 module github.com/sonalys/fake
 
-go 1.22
+go 1.25.0
 
 require (
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/rs/zerolog v1.32.0
 	github.com/stretchr/testify v1.9.0
-	golang.org/x/mod v0.15.0
-	golang.org/x/tools v0.18.0
+	golang.org/x/mod v0.35.0
+	golang.org/x/tools v0.44.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/sys v0.17.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
 )
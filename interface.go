@@ -1,185 +1,115 @@
 package fake
 
 import (
+	"cmp"
 	"fmt"
-	"go/ast"
+	"go/types"
 	"io"
 	"strings"
 
-	"github.com/rs/zerolog/log"
-	"github.com/sonalys/fake/internal/imports"
-	pkgs "github.com/sonalys/fake/internal/packages"
+	"github.com/sonalys/fake/boilerplate"
 )
 
+// ParsedInterface is a type that can be mocked, either an interface or a named function type.
 type ParsedInterface struct {
-	ParsedFile    *ParsedFile
-	Type          *ast.TypeSpec
-	Ref           *ast.InterfaceType
+	ParsedFile *ParsedFile
+	Type       *types.TypeName
+	Ref        *types.Interface
+	// Func is set for named function types, like `type Clock func() time.Time`, instead of Ref.
+	Func          *types.Signature
 	Name          string
 	GenericsTypes []string
 	GenericsNames []string
-	// TranslateGenericNames translates generic type names from any imported interfaces.
-	// Example:
-	//	type A[T any] interface{ B[T] }
-	//	type B[J any] interface{ Method() J }
-	// it should have method Method() T when implementing A mock.
-	TranslateGenericNames []string
+	// MockName is the name of the generated mock struct.
+	MockName string
+	// Fallback is the default behavior of the mock for unexpected calls.
+	Fallback boilerplate.Fallback
+	// Spy enables the generation of the spy constructor.
+	Spy bool
 
 	fieldsCache []*ParsedField
 }
 
-func (i *ParsedInterface) ListFields() []*ParsedField {
-	if i.fieldsCache != nil {
-		return i.fieldsCache
+// parseInterface looks up an exported interface or function type declared in the file package.
+// Constraint interfaces, like unions, are ignored because they cannot be implemented by mocks.
+// Interfaces skipped by the package config are also ignored.
+func (f *ParsedFile) parseInterface(name string) *ParsedInterface {
+	typeName, ok := f.Package.Scope().Lookup(name).(*types.TypeName)
+	if !ok || !typeName.Exported() {
+		return nil
 	}
-	i.fieldsCache = i.ParsedFile.Generator.listInterfaceFields(i, i.ParsedFile.Imports)
-	return i.fieldsCache
-}
-
-// ListInterfaceFields receives an interface to translate fields into fields.
-// It cannot be a ParsedInterface method because we need to translate imports from the original file,
-// some interfaces are originated from external packages.
-func (g *Generator) listInterfaceFields(i *ParsedInterface, imports map[string]*imports.ImportEntry) []*ParsedField {
-	if i == nil || i.Ref.Methods == nil {
+	var ref *types.Interface
+	var signature *types.Signature
+	switch underlying := typeName.Type().Underlying().(type) {
+	case *types.Interface:
+		if !underlying.IsMethodSet() {
+			return nil
+		}
+		ref = underlying
+	case *types.Signature:
+		signature = underlying
+	default:
 		return nil
 	}
-	if i.fieldsCache != nil {
-		return i.fieldsCache
+	options := f.Config.Interfaces[name]
+	if options.Skip {
+		return nil
 	}
-	var resp []*ParsedField
-	for _, field := range i.Ref.Methods.List {
-		switch t := field.Type.(type) {
-		case *ast.FuncType:
-			for _, name := range field.Names {
-				resp = append(resp, &ParsedField{
-					Interface: i,
-					Ref:       field,
-					Name:      name.Name,
-				})
-			}
-		case *ast.SelectorExpr:
-			// Interface from another package.
-			resp = append(resp, g.listInterfaceFields(g.parseInterface(t, i.ParsedFile), imports)...)
-		case *ast.IndexExpr:
-			// Interface from same package.
-			// Child interface has only 1 generic parameter.
-			ident := t.X.(*ast.Ident)
-			newInterface := i.ParsedFile.FindInterfaceByName(ident.Name)
-			index, ok := t.Index.(*ast.Ident)
-			if !ok {
-				log.Fatal().Msgf("unexpected type of *ast.FuncType: expected *ast.Ident, got %T", t.Index)
-			}
-			newInterface.TranslateGenericNames = []string{index.Name}
-			newInterface.GenericsNames[0] = i.printAstExpr(index)
-			// IndexExpr means 1 parameter, so newInterface only has 1 type and 1 name.
-			newInterface.Type.TypeParams.List[0].Names[0].Name = index.Name
-			resp = append(resp, g.listInterfaceFields(newInterface, imports)...)
-		case *ast.IndexListExpr:
-			// Interface from same package.
-			// Child interface has many generic parameter.
-			ident := t.X.(*ast.Ident)
-			newInterface := i.ParsedFile.FindInterfaceByName(ident.Name)
-			fields := g.listInterfaceFields(newInterface, imports)
-			var names []string
-			for _, index := range t.Indices {
-				names = append(names, i.printAstExpr(index))
-			}
-			newInterface.TranslateGenericNames = names
-			resp = append(resp, fields...)
-		}
+	i := &ParsedInterface{
+		ParsedFile: f,
+		Type:       typeName,
+		Ref:        ref,
+		Func:       signature,
+		Name:       name,
+		MockName:   cmp.Or(options.MockName, fmt.Sprintf("%sMock", name)),
+		Fallback:   f.Generator.Fallback,
+		// Function types have no spies, the real function can be called directly from On.
+		Spy: signature == nil && (options.Spy == nil || *options.Spy),
 	}
-
-	// Deduplicate resp entries with the same name
-	nameMap := make(map[string]bool)
-	deduplicatedResp := []*ParsedField{}
-	for _, field := range resp {
-		if !nameMap[field.Name] {
-			deduplicatedResp = append(deduplicatedResp, field)
-			nameMap[field.Name] = true
+	// Spies cannot delegate unexported methods to the real implementation.
+	for idx := 0; ref != nil && idx < ref.NumMethods(); idx++ {
+		if !ref.Method(idx).Exported() {
+			i.Spy = false
 		}
 	}
-	resp = deduplicatedResp
-	i.fieldsCache = resp
-	return resp
-}
-
-func (f *ParsedInterface) getTypeGenerics(t *ast.TypeSpec) ([]string, []string) {
-	var genericsTypes []string
-	var genericsNames []string
-	if t.TypeParams != nil {
-		for _, t := range t.TypeParams.List {
-			for _, name := range t.Names {
-				genericsTypes = append(genericsTypes, f.printAstExpr(t.Type))
-				genericsNames = append(genericsNames, name.Name)
-			}
+	// Interface overrides take precedence over package overrides.
+	for _, fallback := range []*boilerplate.Fallback{f.Config.Fallback, options.Fallback} {
+		if fallback != nil {
+			i.Fallback = *fallback
 		}
 	}
-	return genericsTypes, genericsNames
-}
-
-func (f *ParsedFile) FindInterfaceByName(name string) *ParsedInterface {
-	for _, i := range f.ListInterfaces() {
-		if i.Name == name {
-			return i
+	// Both named types and aliases can have type parameters.
+	if generic, ok := typeName.Type().(interface{ TypeParams() *types.TypeParamList }); ok {
+		typeParams := generic.TypeParams()
+		for idx := range typeParams.Len() {
+			typeParam := typeParams.At(idx)
+			i.GenericsNames = append(i.GenericsNames, typeParam.Obj().Name())
+			i.GenericsTypes = append(i.GenericsTypes, f.printType(typeParam.Constraint()))
 		}
 	}
-	return nil
+	return i
 }
 
-func (g *Generator) parseInterface(ident *ast.SelectorExpr, f *ParsedFile) *ParsedInterface {
-	interfaceName := ident.Sel.Name
-	// Packages can have different names than their path, Example: ctx "context" would return ctx.
-	pkgName := ident.X.(*ast.Ident).Name
-	pkgInfo, ok := f.Imports[pkgName]
-	if !ok {
-		return nil
-	}
-	pkg, ok := pkgs.Parse(g.goModFilename, pkgInfo.Path)
-	if !ok {
-		return nil
+// ListFields returns the interface method set, including methods from embedded interfaces.
+// Function types have a single field, without name.
+func (i *ParsedInterface) ListFields() []*ParsedField {
+	if i.fieldsCache != nil {
+		return i.fieldsCache
 	}
-	var i *ParsedInterface
-	var externalFile *ParsedFile
-	var err error
-	for _, filename := range pkg.Files {
-		externalFile, err = g.ParseFile(filename)
-		if err != nil {
-			log.Fatal().Msgf("failed to parse file: %s", filename)
-		}
-		i = externalFile.FindInterfaceByName(interfaceName)
-		if i != nil {
-			break
-		}
+	if i.Func != nil {
+		i.fieldsCache = []*ParsedField{{Interface: i, Signature: i.Func}}
+		return i.fieldsCache
 	}
-	oldImportList := externalFile.Imports
-	externalFile.OriginalImports = oldImportList
-	// If different imports collide with same name, we alias the new imports being used.
-	for name, info := range oldImportList {
-		// If the pkg path is already there, then entry was already added.
-		if _, ok := f.ImportsPathMap[info.Path]; ok {
-			continue
-		}
-		currentEntry, collides := f.Imports[name]
-		if !collides {
-			f.Imports[name] = info
-			f.ImportsPathMap[info.Path] = info
-			continue
-		}
-		if collides && info.Path != currentEntry.Path {
-			alias := fmt.Sprintf("%s1", name)
-			info.Alias = alias
-			f.Imports[alias] = info
-			f.ImportsPathMap[info.Path] = info
-		}
+	for idx := range i.Ref.NumMethods() {
+		method := i.Ref.Method(idx)
+		i.fieldsCache = append(i.fieldsCache, &ParsedField{
+			Interface: i,
+			Ref:       method,
+			Signature: method.Type().(*types.Signature),
+			Name:      method.Name(),
+		})
 	}
-	externalFile.UsedImports = f.UsedImports
-	externalFile.Imports = f.Imports
-	externalFile.ImportsPathMap = f.ImportsPathMap
-	return i
-}
-
-func (i *ParsedInterface) getGenericsInfo() ([]string, []string) {
-	return i.getTypeGenerics(i.Type)
+	return i.fieldsCache
 }
 
 func (i *ParsedInterface) writeGenericsHeader() string {
@@ -201,14 +131,27 @@ func (i *ParsedInterface) writeGenericsNameHeader() string {
 }
 
 func (i *ParsedInterface) getMockName() string {
-	return fmt.Sprintf("%sMock", i.Name)
+	return i.MockName
+}
+
+// getInterfaceType returns the original interface type, as used from the mock package.
+func (i *ParsedInterface) getInterfaceType() string {
+	pkgAlias := i.ParsedFile.Imports.Use(i.ParsedFile.PkgPath, i.ParsedFile.PkgName)
+	if pkgAlias == "" {
+		return i.Name + i.writeGenericsNameHeader()
+	}
+	return fmt.Sprintf("%s.%s%s", pkgAlias, i.Name, i.writeGenericsNameHeader())
 }
 
 func (i *ParsedInterface) writeStruct(w io.Writer) {
-	// Write struct definition implementing the interface
+	fmt.Fprintf(w, "// %s is a mock of %s.%s.\n", i.getMockName(), i.ParsedFile.PkgName, i.Name)
 	fmt.Fprintf(w, "type %s%s struct {\n", i.getMockName(), i.writeGenericsHeader())
+	if i.Spy {
+		fmt.Fprintf(w, "\t// real is the implementation called by spies when no function is registered.\n")
+		fmt.Fprintf(w, "\treal %s\n", i.getInterfaceType())
+	}
 	for _, field := range i.ListFields() {
-		fmt.Fprintf(w, "\tsetup%s mockSetup.Mock[", field.Name)
+		fmt.Fprintf(w, "\tsetup%s *mockSetup.Mock[", field.Name)
 		i.PrintMethodHeader(w, "func", field)
 		fmt.Fprintf(w, "]\n")
 	}
@@ -217,84 +160,393 @@ func (i *ParsedInterface) writeStruct(w io.Writer) {
 
 func (i *ParsedInterface) writeInitializer(w io.Writer) {
 	genericsNameHeader := i.writeGenericsNameHeader()
-	fmt.Fprintf(w, "func New%s%s(t *testing.T) *%s%s {\n", i.getMockName(), i.writeGenericsHeader(), i.getMockName(), genericsNameHeader)
-	fmt.Fprintf(w, "\treturn &%s%s{\n", i.getMockName(), genericsNameHeader)
+	fmt.Fprintf(w, "func New%s%s(t mockSetup.TestingT) *%s%s {\n", i.getMockName(), i.writeGenericsHeader(), i.getMockName(), genericsNameHeader)
+	fmt.Fprintf(w, "\tt.Helper()\n")
+	fmt.Fprintf(w, "\ts := &%s%s{\n", i.getMockName(), genericsNameHeader)
 	for _, field := range i.ListFields() {
 		fmt.Fprintf(w, "\t\tsetup%s: mockSetup.NewMock[", field.Name)
 		i.PrintMethodHeader(w, "func", field)
-		fmt.Fprintf(w, "](t),\n")
+		fmt.Fprintf(w, "](t, %q, %q),\n", i.getMockName(), field.displayName())
 	}
 	fmt.Fprintf(w, "\t}\n")
+	if i.Fallback != boilerplate.FallbackPanic {
+		// SetFallback is not generated when the interface has a method with the same name.
+		if i.hasMethod("SetFallback") {
+			for _, field := range i.ListFields() {
+				fmt.Fprintf(w, "\ts.setup%s.SetFallback(%s)\n", field.Name, printFallback(i.Fallback))
+			}
+		} else {
+			fmt.Fprintf(w, "\ts.SetFallback(%s)\n", printFallback(i.Fallback))
+		}
+	}
+	fmt.Fprintf(w, "\treturn s\n")
+	fmt.Fprintf(w, "}\n\n")
+}
+
+// printFallback returns the boilerplate constant name for the given fallback.
+func printFallback(fallback boilerplate.Fallback) string {
+	name := fallback.String()
+	return fmt.Sprintf("mockSetup.Fallback%s%s", strings.ToUpper(name[:1]), name[1:])
+}
+
+// writeFallback writes the fallback setters, skipping the ones named like the interface methods.
+func (i *ParsedInterface) writeFallback(w io.Writer) {
+	if !i.hasMethod("SetFallback") {
+		fmt.Fprintf(w, "// SetFallback sets how unexpected calls are handled by all methods.\n")
+		fmt.Fprintf(w, "func (s *%s%s) SetFallback(fallback mockSetup.Fallback) {\n", i.getMockName(), i.writeGenericsNameHeader())
+		for _, field := range i.ListFields() {
+			fmt.Fprintf(w, "\ts.setup%s.SetFallback(fallback)\n", field.Name)
+		}
+		fmt.Fprintf(w, "}\n\n")
+	}
+	for _, field := range i.ListFields() {
+		i.writeMethodFallback(w, field)
+	}
+}
+
+func (i *ParsedInterface) writeMethodFallback(w io.Writer, field *ParsedField) {
+	if i.hasMethod(fmt.Sprintf("Set%sFallback", field.Name)) {
+		return
+	}
+	fmt.Fprintf(w, "// Set%sFallback sets how unexpected calls to %s are handled.\n", field.Name, field.displayName())
+	fmt.Fprintf(w, "func (s *%s%s) Set%sFallback(fallback mockSetup.Fallback) {\n", i.getMockName(), i.writeGenericsNameHeader(), field.Name)
+	fmt.Fprintf(w, "\ts.setup%s.SetFallback(fallback)\n", field.Name)
+	fmt.Fprintf(w, "}\n\n")
+}
+
+func (i *ParsedInterface) writeSpyInitializer(w io.Writer) {
+	genericsNameHeader := i.writeGenericsNameHeader()
+	fmt.Fprintf(w, "// New%sSpy creates a mock that delegates calls to real, unless a function is registered for them.\n", i.Name)
+	fmt.Fprintf(w, "// Calls are recorded and registered expectations are still asserted.\n")
+	fmt.Fprintf(w, "func New%sSpy%s(t mockSetup.TestingT, real %s) *%s%s {\n", i.Name, i.writeGenericsHeader(), i.getInterfaceType(), i.getMockName(), genericsNameHeader)
+	fmt.Fprintf(w, "\tt.Helper()\n")
+	fmt.Fprintf(w, "\ts := New%s%s(t)\n", i.getMockName(), genericsNameHeader)
+	fmt.Fprintf(w, "\ts.real = real\n")
+	fmt.Fprintf(w, "\treturn s\n")
 	fmt.Fprintf(w, "}\n\n")
 }
 
 func (i *ParsedInterface) writeAssertExpectations(w io.Writer) {
 	genericsTypeHeader := i.writeGenericsNameHeader()
-	fmt.Fprintf(w, "func (s *%s%s) AssertExpectations(t *testing.T) bool {\n", i.getMockName(), genericsTypeHeader)
-	fmt.Fprintf(w, "\treturn ")
+	fmt.Fprintf(w, "func (s *%s%s) AssertExpectations(t mockSetup.TestingT) bool {\n", i.getMockName(), genericsTypeHeader)
+	fmt.Fprintf(w, "\tt.Helper()\n")
+	fmt.Fprintf(w, "\tok := true\n")
+	// All methods are asserted, so every failure is reported.
 	for _, field := range i.ListFields() {
-		fmt.Fprintf(w, "s.setup%s.AssertExpectations(t) &&\n\t\t", field.Name)
+		fmt.Fprintf(w, "\tok = s.setup%s.AssertExpectations(t) && ok\n", field.Name)
 	}
-	fmt.Fprintf(w, "true\n")
+	fmt.Fprintf(w, "\treturn ok\n")
+	fmt.Fprintf(w, "}\n\n")
+}
+
+// writeScope writes Reset and Scope, which manage the expectations of all methods.
+// They are skipped when the interface has methods with the same names.
+func (i *ParsedInterface) writeScope(w io.Writer) {
+	mockType := i.getMockName() + i.writeGenericsNameHeader()
+	if !i.hasMethod("Reset") {
+		fmt.Fprintf(w, "// Reset removes the expectations and recorded calls from all methods.\n")
+		fmt.Fprintf(w, "func (s *%s) Reset() {\n", mockType)
+		for _, field := range i.ListFields() {
+			fmt.Fprintf(w, "\ts.setup%s.Reset()\n", field.Name)
+		}
+		fmt.Fprintf(w, "}\n\n")
+	}
+	if i.hasMethod("Scope") {
+		return
+	}
+	fmt.Fprintf(w, "// Scope returns a mock sharing the calls of s, in which new expectations are asserted when t finishes.\n")
+	fmt.Fprintf(w, "// Expectations registered on s are kept, so it's used to scope expectations to subtests.\n")
+	fmt.Fprintf(w, "func (s *%s) Scope(t mockSetup.TestingT) *%s {\n", mockType, mockType)
+	fmt.Fprintf(w, "\tt.Helper()\n")
+	fmt.Fprintf(w, "\treturn &%s{\n", mockType)
+	if i.Spy {
+		fmt.Fprintf(w, "\t\treal: s.real,\n")
+	}
+	for _, field := range i.ListFields() {
+		fmt.Fprintf(w, "\t\tsetup%s: s.setup%s.Scope(t),\n", field.Name, field.Name)
+	}
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "}\n\n")
+}
+
+// hasMethod reports whether the interface has a method with the given name.
+func (i *ParsedInterface) hasMethod(name string) bool {
+	for _, field := range i.ListFields() {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// writeWait writes the helpers waiting for calls from other goroutines, observing all methods of the mock.
+// They are skipped when the interface has methods with the same names.
+func (i *ParsedInterface) writeWait(w io.Writer) {
+	writeWaitForCalls, writeEventually := !i.hasMethod("WaitForCalls"), !i.hasMethod("Eventually")
+	if !writeWaitForCalls && !writeEventually {
+		return
+	}
+	contextAlias := i.ParsedFile.Imports.Use("context", "context")
+	setups := make([]string, 0, len(i.ListFields()))
+	for _, field := range i.ListFields() {
+		setups = append(setups, fmt.Sprintf("s.setup%s", field.Name))
+	}
+	if writeWaitForCalls {
+		fmt.Fprintf(w, "// WaitForCalls waits until the mock receives n calls, or until ctx is done.\n")
+		fmt.Fprintf(w, "func (s *%s%s) WaitForCalls(ctx %s.Context, n int) error {\n", i.getMockName(), i.writeGenericsNameHeader(), contextAlias)
+		fmt.Fprintf(w, "\treturn mockSetup.WaitForCalls(ctx, n, %s)\n", strings.Join(setups, ", "))
+		fmt.Fprintf(w, "}\n\n")
+	}
+	if !writeEventually {
+		return
+	}
+	fmt.Fprintf(w, "// Eventually waits until all expectations registered on the mock are met, or until ctx is done.\n")
+	fmt.Fprintf(w, "func (s *%s%s) Eventually(ctx %s.Context) error {\n", i.getMockName(), i.writeGenericsNameHeader(), contextAlias)
+	fmt.Fprintf(w, "\treturn mockSetup.Eventually(ctx, %s)\n", strings.Join(setups, ", "))
+	fmt.Fprintf(w, "}\n\n")
+}
+
+func (i *ParsedInterface) getConfigName(methodName string) string {
+	return fmt.Sprintf("%s%sConfig", i.getMockName(), methodName)
+}
+
+// writeConfig writes the method config type, with typed helpers for the method results.
+func (i *ParsedInterface) writeConfig(w io.Writer, methodName string, f *ParsedField) {
+	configName := i.getConfigName(methodName)
+	genericsNameHeader := i.writeGenericsNameHeader()
+	fmt.Fprintf(w, "// %s configures calls to %s.\n", configName, f.displayName())
+	fmt.Fprintf(w, "type %s%s struct {\n", configName, i.writeGenericsHeader())
+	fmt.Fprintf(w, "\tmockSetup.Expectation[")
+	i.PrintMethodHeader(w, "func", f)
+	fmt.Fprintf(w, "]\n")
+	fmt.Fprintf(w, "}\n\n")
+
+	if f.HasContext() {
+		i.writeDelay(w, methodName, f)
+	}
+	resultTypes := f.ResultTypes()
+	if len(resultTypes) == 0 {
+		return
+	}
+	resultNames := f.ResultNames()
+	params := make([]string, 0, len(resultTypes))
+	for idx, typeName := range resultTypes {
+		params = append(params, fmt.Sprintf("%s %s", resultNames[idx], typeName))
+	}
+	fmt.Fprintf(w, "// Return sets the values returned by calls to %s.\n", f.displayName())
+	fmt.Fprintf(w, "func (c %s%s) Return(%s) mockSetup.Config {\n", configName, genericsNameHeader, strings.Join(params, ", "))
+	// The function results are unnamed, so they don't shadow the Return parameters.
+	fmt.Fprintf(w, "\treturn c.Do(func")
+	i.WriteMethodParams(w, f)
+	fmt.Fprintf(w, " (%s) {\n\t\treturn %s\n\t})\n", strings.Join(resultTypes, ", "), strings.Join(resultNames, ", "))
+	fmt.Fprintf(w, "}\n\n")
+
+	lastIdx := len(resultTypes) - 1
+	if resultTypes[lastIdx] != "error" {
+		return
+	}
+	if !f.NamedResults() {
+		resultNames[lastIdx] = "err"
+	}
+	fmt.Fprintf(w, "// ReturnErr sets the error returned by calls to %s, other results are zero values.\n", f.displayName())
+	fmt.Fprintf(w, "func (c %s%s) ReturnErr(%s error) mockSetup.Config {\n", configName, genericsNameHeader, resultNames[lastIdx])
+	for idx, typeName := range resultTypes[:lastIdx] {
+		fmt.Fprintf(w, "\tvar %s %s\n", resultNames[idx], typeName)
+	}
+	fmt.Fprintf(w, "\treturn c.Return(%s)\n", strings.Join(resultNames, ", "))
+	fmt.Fprintf(w, "}\n\n")
+}
+
+// writeDelay writes the config options delaying calls, for methods receiving a context as their first parameter.
+func (i *ParsedInterface) writeDelay(w io.Writer, methodName string, f *ParsedField) {
+	configName := i.getConfigName(methodName) + i.writeGenericsNameHeader()
+	timeAlias := i.ParsedFile.Imports.Use("time", "time")
+	fmt.Fprintf(w, "// Block makes the calls to %s drawing this expectation wait until their context is done.\n", f.displayName())
+	fmt.Fprintf(w, "func (c %s) Block() %s {\n", configName, configName)
+	fmt.Fprintf(w, "\tmockSetup.Block(c.Expectation)\n")
+	fmt.Fprintf(w, "\treturn c\n")
+	fmt.Fprintf(w, "}\n\n")
+	fmt.Fprintf(w, "// Delay makes the calls to %s drawing this expectation wait for d before running, or until their context is done.\n", f.displayName())
+	fmt.Fprintf(w, "func (c %s) Delay(d %s.Duration) %s {\n", configName, timeAlias, configName)
+	fmt.Fprintf(w, "\tmockSetup.Delay(c.Expectation, d)\n")
+	fmt.Fprintf(w, "\treturn c\n")
 	fmt.Fprintf(w, "}\n\n")
 }
 
 func (i *ParsedInterface) writeOnMethod(w io.Writer, methodName string, f *ParsedField) {
+	configName := i.getConfigName(methodName) + i.writeGenericsNameHeader()
+	fmt.Fprintf(w, "// On%s registers a group of functions to be called by %s.\n", methodName, f.displayName())
+	fmt.Fprintf(w, "// Without functions, the values returned can be set with Return.\n")
+	writeDeprecation(w, f.doc())
 	fmt.Fprintf(w, "func (s *%s%s) On%s(funcs ...", i.getMockName(), i.writeGenericsNameHeader(), methodName)
 	i.PrintMethodHeader(w, "func", f)
-	fmt.Fprintf(w, ") mockSetup.Config {\n")
-	fmt.Fprintf(w, "\treturn s.setup%s.Append(funcs...)\n", methodName)
+	fmt.Fprintf(w, ") %s {\n", configName)
+	fmt.Fprintf(w, "\treturn %s{s.setup%s.Append(funcs...)}\n", configName, methodName)
+	fmt.Fprintf(w, "}\n\n")
+}
+
+func (i *ParsedInterface) writeExpectMethod(w io.Writer, methodName string, f *ParsedField) {
+	var params []string
+	argNames := f.ParamNames()
+	for _, name := range argNames {
+		params = append(params, fmt.Sprintf("%s any", name))
+	}
+	fmt.Fprintf(w, "// Expect%s registers calls to %s matching the given arguments.\n", methodName, f.displayName())
+	fmt.Fprintf(w, "// Each argument can be either a literal value, a matcher from github.com/sonalys/fake/matchers or an ArgCaptor from github.com/sonalys/fake/boilerplate.\n")
+	writeDeprecation(w, f.doc())
+	configName := i.getConfigName(methodName) + i.writeGenericsNameHeader()
+	fmt.Fprintf(w, "func (s *%s%s) Expect%s(%s) %s {\n", i.getMockName(), i.writeGenericsNameHeader(), methodName, strings.Join(params, ", "), configName)
+	fmt.Fprintf(w, "\treturn %s{s.setup%s.Expect(%s)}\n", configName, methodName, strings.Join(argNames, ", "))
 	fmt.Fprintf(w, "}\n\n")
 }
 
 func (i *ParsedInterface) writeMethod(w io.Writer, methodName string, f *ParsedField) {
+	writeDoc(w, f.doc())
 	fmt.Fprintf(w, "func (s *%s%s) ", i.getMockName(), i.writeGenericsNameHeader())
 	i.PrintMethodHeader(w, methodName, f)
 	fmt.Fprintf(w, "{\n")
-	var callingNames []string
-	funcType := f.Ref.Type.(*ast.FuncType)
-	for i := range funcType.Params.List {
-		callingNames = append(callingNames, getFieldCallingName(i, funcType.Params.List[i]))
-	}
-	var argNames []string
-	var argFlag []string
-	for i := range funcType.Params.List {
-		argNames = append(argNames, getFieldName(i))
-		nameLen := len(funcType.Params.List[i].Names)
-		if nameLen == 0 {
-			nameLen = 1
+	i.writeMethodBody(w, methodName, f)
+	fmt.Fprintf(w, "}\n\n")
+}
+
+// writeMethodBody writes the statements handling a call, drawing a function from the method deck.
+func (i *ParsedInterface) writeMethodBody(w io.Writer, methodName string, f *ParsedField) {
+	argNames, callingNames := f.ParamNames(), f.CallingNames()
+	fmt.Fprintf(w, "\tinvocation := s.setup%s.Record(%s)\n", methodName, strings.Join(argNames, ", "))
+	if f.HasContext() {
+		fmt.Fprintf(w, "\tf, ok, ctxErr := s.setup%s.CallContext(%s, %s)\n", methodName, argNames[0], strings.Join(argNames, ", "))
+	} else {
+		fmt.Fprintf(w, "\tf, ok := s.setup%s.Call(%s)\n", methodName, strings.Join(argNames, ", "))
+	}
+	resultNames := f.ResultNames()
+	resultTypes := f.ResultTypes()
+	// Named results are already declared by the signature.
+	if !f.NamedResults() {
+		for idx, typeName := range resultTypes {
+			fmt.Fprintf(w, "\tvar %s %s\n", resultNames[idx], typeName)
 		}
-		for j := 0; j < nameLen; j++ {
-			argFlag = append(argFlag, "%v")
+	}
+	var assign string
+	if len(resultNames) > 0 {
+		assign = fmt.Sprintf("%s = ", strings.Join(resultNames, ", "))
+	}
+	fmt.Fprintf(w, "\tswitch {\n")
+	if f.HasContext() {
+		fmt.Fprintf(w, "\tcase ctxErr != nil:\n")
+		if lastIdx := len(resultTypes) - 1; lastIdx >= 0 && resultTypes[lastIdx] == "error" {
+			fmt.Fprintf(w, "\t\t// Delayed calls return the context error when it's done first.\n")
+			fmt.Fprintf(w, "\t\t%s = ctxErr\n", resultNames[lastIdx])
+		} else {
+			fmt.Fprintf(w, "\t\t// Delayed calls return zero values when their context is done first.\n")
 		}
 	}
-	fmt.Fprintf(w, "\tf, ok := s.setup%s.Call()\n", methodName)
-	fmt.Fprintf(w, "\tif !ok {\n")
-	fmt.Fprintf(
-		w, "\t\tpanic(fmt.Sprintf(\"unexpected call %s(%s)\", %v))\n",
-		methodName, strings.Join(argFlag, ","), strings.Join(argNames, ","),
-	)
+	fmt.Fprintf(w, "\tcase ok && *f != nil:\n")
+	fmt.Fprintf(w, "\t\t%s(*f)(%s)\n", assign, strings.Join(callingNames, ","))
+	if i.Spy {
+		fmt.Fprintf(w, "\tcase s.real != nil:\n")
+		fmt.Fprintf(w, "\t\t// Spies delegate unexpected calls, and expectations without functions, to the real implementation.\n")
+		fmt.Fprintf(w, "\t\t%ss.real.%s(%s)\n", assign, methodName, strings.Join(callingNames, ","))
+	}
+	fmt.Fprintf(w, "\tcase ok:\n")
+	fmt.Fprintf(w, "\t\t// Expectations without functions return zero values.\n")
+	fmt.Fprintf(w, "\tdefault:\n")
+	unexpectedArgs := append([]string{fmt.Sprintf("%q", f.displayName())}, argNames...)
+	fmt.Fprintf(w, "\t\ts.setup%s.Unexpected(%s)\n", methodName, strings.Join(unexpectedArgs, ", "))
 	fmt.Fprintf(w, "\t}\n")
-	if funcType.Results.NumFields() > 0 {
-		fmt.Fprintf(w, "\treturn (*f)(%s)\n", strings.Join(callingNames, ","))
-	} else {
-		fmt.Fprintf(w, "\t(*f)(%s)\n", strings.Join(callingNames, ","))
+	fmt.Fprintf(w, "\tinvocation.SetResults(%s)\n", strings.Join(resultNames, ", "))
+	if len(resultNames) > 0 {
+		fmt.Fprintf(w, "\treturn %s\n", strings.Join(resultNames, ", "))
+	}
+}
+
+func (i *ParsedInterface) getCallName(methodName string) string {
+	return fmt.Sprintf("%s%sCall", i.getMockName(), methodName)
+}
+
+// writeCallHistory writes the typed call record for the method, and its accessor.
+func (i *ParsedInterface) writeCallHistory(w io.Writer, methodName string, f *ParsedField) {
+	callName := i.getCallName(methodName)
+	genericsNameHeader := i.writeGenericsNameHeader()
+	paramTypes, resultTypes := f.ParamTypes(), f.ResultTypes()
+	fmt.Fprintf(w, "// %s is the record of a call to %s.\n", callName, f.displayName())
+	fmt.Fprintf(w, "type %s%s struct {\n", callName, i.writeGenericsHeader())
+	fmt.Fprintf(w, "\tmockSetup.Invocation\n")
+	for idx, typeName := range paramTypes {
+		fmt.Fprintf(w, "\tA%d %s\n", idx, typeName)
+	}
+	for idx, typeName := range resultTypes {
+		fmt.Fprintf(w, "\tR%d %s\n", idx, typeName)
 	}
 	fmt.Fprintf(w, "}\n\n")
+
+	// The accessor is skipped when the interface has a method with the same name.
+	if i.hasMethod(methodName + "Calls") {
+		return
+	}
+	fmt.Fprintf(w, "// %sCalls returns all calls received by %s, in order.\n", methodName, f.displayName())
+	fmt.Fprintf(w, "func (s *%s%s) %sCalls() []%s%s {\n", i.getMockName(), genericsNameHeader, methodName, callName, genericsNameHeader)
+	fmt.Fprintf(w, "\tinvocations := s.setup%s.Invocations()\n", methodName)
+	fmt.Fprintf(w, "\tresp := make([]%s%s, 0, len(invocations))\n", callName, genericsNameHeader)
+	fmt.Fprintf(w, "\tfor _, invocation := range invocations {\n")
+	fmt.Fprintf(w, "\t\tcall := %s%s{Invocation: invocation}\n", callName, genericsNameHeader)
+	for idx, typeName := range paramTypes {
+		fmt.Fprintf(w, "\t\tcall.A%d, _ = invocation.Args[%d].(%s)\n", idx, idx, typeName)
+	}
+	if len(resultTypes) > 0 {
+		// Calls in progress have no results yet.
+		fmt.Fprintf(w, "\t\tif len(invocation.Results) > 0 {\n")
+		for idx, typeName := range resultTypes {
+			fmt.Fprintf(w, "\t\t\tcall.R%d, _ = invocation.Results[%d].(%s)\n", idx, idx, typeName)
+		}
+		fmt.Fprintf(w, "\t\t}\n")
+	}
+	fmt.Fprintf(w, "\t\tresp = append(resp, call)\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn resp\n")
+	fmt.Fprintf(w, "}\n\n")
+}
+
+// writeCalls writes the accessor merging the calls from all methods, unless the interface has a Calls method.
+func (i *ParsedInterface) writeCalls(w io.Writer) {
+	if i.hasMethod("Calls") {
+		return
+	}
+	fmt.Fprintf(w, "// Calls returns all calls received by the mock, in order.\n")
+	fmt.Fprintf(w, "func (s *%s%s) Calls() []mockSetup.Invocation {\n", i.getMockName(), i.writeGenericsNameHeader())
+	fmt.Fprintf(w, "\treturn mockSetup.Timeline(map[string][]mockSetup.Invocation{\n")
+	for _, field := range i.ListFields() {
+		fmt.Fprintf(w, "\t\t\"%s\": s.setup%s.Invocations(),\n", field.Name, field.Name)
+	}
+	fmt.Fprintf(w, "\t})\n")
+	fmt.Fprintf(w, "}\n\n")
 }
 
 func (i *ParsedInterface) writeStructMethods(file io.Writer) {
 	// Implement each method in the interface with dummy bodies.
-	for _, field := range i.ParsedFile.Generator.listInterfaceFields(i, i.ParsedFile.Imports) {
+	for _, field := range i.ListFields() {
 		methodName := field.Name
+		i.writeConfig(file, methodName, field)
 		i.writeOnMethod(file, methodName, field)
+		i.writeExpectMethod(file, methodName, field)
 		i.writeMethod(file, methodName, field)
+		i.writeCallHistory(file, methodName, field)
 	}
 }
 
 func (i *ParsedInterface) write(w io.Writer) {
+	if i.Func != nil {
+		i.writeFuncMock(w)
+		return
+	}
 	i.writeStruct(w)
 	i.writeInitializer(w)
+	if i.Spy {
+		i.writeSpyInitializer(w)
+	}
 	i.writeAssertExpectations(w)
+	i.writeScope(w)
+	i.writeWait(w)
+	i.writeFallback(w)
+	i.writeCalls(w)
 	i.writeStructMethods(w)
 }
@@ -0,0 +1,34 @@
+package caching
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/sonalys/fake/internal/packages"
+)
+
+// GetUncachedPackage compares an external package with the lock file from its mocks folder, outputDir.
+// The package is changed if its files, module version or selected interfaces differ from the lock file.
+func GetUncachedPackage(pkg *packages.PackageInfo, interfaces []string, outputDir string) (*HashedLockFile, error) {
+	lockFile, err := readLockFile(filepath.Join(outputDir, lockFilename))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s file: %w", lockFilename, err)
+	}
+	hash, err := hashFiles(pkg.Files...)
+	if err != nil {
+		return nil, fmt.Errorf("hashing package %s: %w", pkg.Path, err)
+	}
+	entry := &HashedLockFile{
+		Hash:       hash,
+		Module:     pkg.Module,
+		Interfaces: interfaces,
+		exists:     true,
+	}
+	cached, ok := lockFile[pkg.Path]
+	if ok && cached.Hash == hash && cached.Module == pkg.Module && slices.Equal(cached.Interfaces, interfaces) {
+		return entry, nil
+	}
+	entry.changed = true
+	return entry, nil
+}
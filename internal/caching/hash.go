@@ -5,16 +5,18 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"io"
 	"os"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
 
-	"github.com/rs/zerolog/log"
 	"github.com/sonalys/fake/internal/files"
 	"github.com/sonalys/fake/internal/gosum"
-	"golang.org/x/tools/go/packages"
+	"github.com/sonalys/fake/internal/platform"
 )
 
 const (
@@ -39,19 +41,21 @@ func getImportsHash(filePath string, dependencies map[string]string) (string, er
 	return b.String(), nil
 }
 
-func GetUncachedFiles(inputs, ignore []string, outputDir string) (map[string]LockfileHandler, error) {
+// GetUncachedFiles compares the go files from inputs with the lock file from outputDir.
+// It also returns the relative path of locked files that no longer exist, so their mocks can be removed.
+func GetUncachedFiles(settings platform.Settings, inputs, ignore []string, outputDir string) (map[string]LockfileHandler, []string, error) {
 	lockFilePath := path.Join(outputDir, lockFilename)
 	groupLockFiles, err := readLockFile(lockFilePath)
 	if err != nil {
-		return nil, fmt.Errorf("reading %s file: %w", lockFilename, err)
+		return nil, nil, fmt.Errorf("reading %s file: %w", lockFilename, err)
 	}
 	dependencies, err := gosum.Parse(inputs[0])
 	if err != nil {
-		return nil, fmt.Errorf("parsing go.sum file on %s: %w", inputs[0], err)
+		return nil, nil, fmt.Errorf("parsing go.sum file on %s: %w", inputs[0], err)
 	}
-	goFiles, err := files.ListGoFiles(inputs, append(ignore, outputDir))
+	goFiles, err := files.ListGoFiles(settings, inputs, append(ignore, outputDir))
 	if err != nil {
-		return nil, fmt.Errorf("listing *.go files: %w", err)
+		return nil, nil, fmt.Errorf("listing *.go files: %w", err)
 	}
 	out := make(map[string]LockfileHandler, len(groupLockFiles))
 
@@ -59,13 +63,13 @@ func GetUncachedFiles(inputs, ignore []string, outputDir string) (map[string]Loc
 
 	gomod, err := files.FindFile(inputs[0], "go.mod")
 	if err != nil {
-		return nil, fmt.Errorf("input is not part of a go module")
+		return nil, nil, fmt.Errorf("input is not part of a go module")
 	}
 
 	for _, absPath := range goFiles {
 		relPath, err := files.GetRelativePath(gomod, absPath)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		entry, ok := groupLockFiles[relPath]
 		// If file is not in lock file hashes, then we delay hash calculation for after the mock generation.
@@ -79,11 +83,11 @@ func GetUncachedFiles(inputs, ignore []string, outputDir string) (map[string]Loc
 		}
 		importsHash, err := getImportsHash(absPath, dependencies)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		hash, err := cachedHasher(absPath)
 		if err != nil {
-			return nil, fmt.Errorf("hashing file: %w", err)
+			return nil, nil, fmt.Errorf("hashing file: %w", err)
 		}
 		if entry.Hash == hash && entry.Dependencies == importsHash {
 			// Mark file as processed, to further delete unused entries.
@@ -100,31 +104,29 @@ func GetUncachedFiles(inputs, ignore []string, outputDir string) (map[string]Loc
 			Dependencies: importsHash,
 		}
 	}
+	var legacy []string
 	for relPath := range groupLockFiles {
 		if _, ok := out[relPath]; !ok {
-			// Remove empty files from our new lock file.
-			rmFileName := files.GenerateOutputFileName(relPath, outputDir)
-			os.Remove(rmFileName)
-			log.Info().Msgf("removing legacy mock from %s", rmFileName)
+			legacy = append(legacy, relPath)
 		}
 	}
-	return out, nil
+	return out, legacy, nil
 }
 
-// loadPackageImports returns a list of imports for a given .go file
+// loadPackageImports returns a list of imports for a given .go file.
+// Only the imports are parsed, avoiding loading the whole package.
 func loadPackageImports(file string) ([]string, error) {
-	cfg := &packages.Config{
-		Mode: packages.NeedImports,
-	}
-	pkgs, err := packages.Load(cfg, file)
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
 	if err != nil {
 		return nil, err
 	}
-	imports := make([]string, 0, 30)
-	for _, pkg := range pkgs {
-		for imp := range pkg.Imports {
-			imports = append(imports, imp)
+	imports := make([]string, 0, len(f.Imports))
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return nil, err
 		}
+		imports = append(imports, importPath)
 	}
 	return imports, nil
 }
@@ -19,6 +19,9 @@ type (
 	HashedLockFile struct {
 		Hash         string `json:"hash"`
 		Dependencies string `json:"dependencies,omitempty"`
+		// Module and Interfaces are only used by external packages.
+		Module     string   `json:"module,omitempty"`
+		Interfaces []string `json:"interfaces,omitempty"`
 		// Changed is used as an in-memory flag to say that a file lock changed.
 		filepath string `json:"-"`
 		changed  bool   `json:"-"`
@@ -93,23 +96,33 @@ func readLockFile(path string) (LockFilePackage, error) {
 	return model, err
 }
 
-/*
-WriteLockFile function takes dir string
-and the target directory (dir), as well as a hash map (hash).
-It saves file at path output/{dir}/fake.lock.json
-*/
-func WriteLockFile(output string, hash map[string]LockfileHandler) error {
+// LockFilePath returns the path of the lock file from the output folder.
+func LockFilePath(output string) string {
+	return filepath.Join(output, lockFilename)
+}
+
+// EncodeLockFile encodes the lock file, keeping only the entries that still exist.
+func EncodeLockFile(hash map[string]LockfileHandler) ([]byte, error) {
 	var out = make(map[string]*HashedLockFile, len(hash))
 	for file, entry := range hash {
 		if entry.Exists() {
 			out[file] = entry.Compute()
 		}
 	}
-	data, err := json.MarshalIndent(out, "", "\t")
+	return json.MarshalIndent(out, "", "\t")
+}
+
+/*
+WriteLockFile function takes dir string
+and the target directory (dir), as well as a hash map (hash).
+It saves file at path output/{dir}/fake.lock.json
+*/
+func WriteLockFile(output string, hash map[string]LockfileHandler) error {
+	data, err := EncodeLockFile(hash)
 	if err != nil {
 		return err
 	}
-	w, err := files.CreateFileAndFolders(filepath.Join(output, lockFilename))
+	w, err := files.CreateFileAndFolders(LockFilePath(output))
 	if err != nil {
 		return err
 	}
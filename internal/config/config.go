@@ -0,0 +1,126 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/sonalys/fake/boilerplate"
+	"github.com/sonalys/fake/internal/files"
+	"gopkg.in/yaml.v3"
+)
+
+// Filename is the name of the configuration file, discovered next to go.mod.
+const Filename = ".fake.yaml"
+
+type (
+	// Interface overrides how the mock of a single interface is generated.
+	Interface struct {
+		// MockName replaces the default <Interface>Mock name.
+		MockName string `yaml:"mockName"`
+		// Fallback sets how unexpected calls are handled by default.
+		Fallback *boilerplate.Fallback `yaml:"fallback"`
+		// Spy controls the generation of the New<Interface>Spy constructor, enabled by default.
+		Spy *bool `yaml:"spy"`
+		// Skip disables the generation of the interface.
+		Skip bool `yaml:"skip"`
+	}
+
+	// Package overrides how mocks are generated for a package.
+	Package struct {
+		// Output is the folder for the package mocks, instead of mirroring the package tree.
+		Output      string                `yaml:"output"`
+		MockPackage string                `yaml:"mockPackage"`
+		Fallback    *boilerplate.Fallback `yaml:"fallback"`
+		// Skip disables the generation of the whole package.
+		Skip       bool                 `yaml:"skip"`
+		Interfaces map[string]Interface `yaml:"interfaces"`
+	}
+
+	// External declares interfaces to be generated from packages outside the module.
+	External struct {
+		// Package is the import path, like net/http.
+		Package string `yaml:"package"`
+		// Interfaces lists the interfaces to be generated, all exported interfaces are generated if empty.
+		Interfaces []string `yaml:"interfaces"`
+	}
+
+	// Config is the project configuration, paths are relative to the configuration file.
+	Config struct {
+		Inputs      []string              `yaml:"inputs"`
+		Ignore      []string              `yaml:"ignore"`
+		Output      string                `yaml:"output"`
+		MockPackage string                `yaml:"mockPackage"`
+		Fallback    *boilerplate.Fallback `yaml:"fallback"`
+		// Packages is keyed by the package folder, relative to the configuration file.
+		Packages map[string]Package `yaml:"packages"`
+		External []External         `yaml:"external"`
+
+		// Dir is the absolute folder of the configuration file.
+		Dir string `yaml:"-"`
+	}
+)
+
+// Find looks for the configuration file next to the go.mod of dir, or its go.work for workspaces without a root module.
+// It returns nil if there is no configuration file.
+func Find(dir string) (*Config, error) {
+	rootPath, err := files.FindFile(dir, "go.mod")
+	if err != nil {
+		if rootPath, err = files.FindFile(dir, "go.work"); err != nil {
+			return nil, fmt.Errorf("could not find go.mod: %w", err)
+		}
+	}
+	c, err := Load(filepath.Join(filepath.Dir(rootPath), Filename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Load reads the configuration file from the given path.
+// Unknown fields are rejected, to avoid silently ignoring typos.
+func Load(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+	if c.Dir, err = filepath.Abs(filepath.Dir(filename)); err != nil {
+		return nil, err
+	}
+	packages := make(map[string]Package, len(c.Packages))
+	for dir, pkg := range c.Packages {
+		packages[path.Clean(dir)] = pkg
+	}
+	c.Packages = packages
+	return &c, nil
+}
+
+// Path resolves a path from the configuration file, relative to its folder.
+func (c *Config) Path(name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(c.Dir, name)
+}
+
+// Package returns the overrides for the package on the absolute folder dir.
+func (c *Config) Package(dir string) Package {
+	if c == nil {
+		return Package{}
+	}
+	relPath, err := filepath.Rel(c.Dir, dir)
+	if err != nil {
+		return Package{}
+	}
+	return c.Packages[filepath.ToSlash(relPath)]
+}
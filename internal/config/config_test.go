@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sonalys/fake/boilerplate"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Find(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/project\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "internal", "users"), os.ModePerm))
+
+	c, err := Find(filepath.Join(dir, "internal", "users"))
+	require.NoError(t, err)
+	require.Nil(t, c)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, Filename), []byte(`
+output: mocks
+fallback: error
+packages:
+  ./internal/users:
+    mockPackage: usersmock
+    interfaces:
+      Repository:
+        mockName: FakeRepository
+        fallback: panic
+        spy: false
+      Internal:
+        skip: true
+external:
+  - package: io
+    interfaces: [Reader]
+`), 0o644))
+	c, err = Find(filepath.Join(dir, "internal", "users"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "mocks"), c.Path(c.Output))
+	require.Equal(t, boilerplate.FallbackError, *c.Fallback)
+	require.Equal(t, []External{{Package: "io", Interfaces: []string{"Reader"}}}, c.External)
+
+	pkg := c.Package(filepath.Join(dir, "internal", "users"))
+	require.Equal(t, "usersmock", pkg.MockPackage)
+	require.Equal(t, "FakeRepository", pkg.Interfaces["Repository"].MockName)
+	require.Equal(t, boilerplate.FallbackPanic, *pkg.Interfaces["Repository"].Fallback)
+	require.False(t, *pkg.Interfaces["Repository"].Spy)
+	require.True(t, pkg.Interfaces["Internal"].Skip)
+	require.Empty(t, c.Package(dir))
+}
+
+func Test_Load_UnknownField(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), Filename)
+	require.NoError(t, os.WriteFile(filename, []byte("outptu: mocks\n"), 0o644))
+	_, err := Load(filename)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filename, []byte("fallback: loud\n"), 0o644))
+	_, err = Load(filename)
+	require.Error(t, err)
+}
@@ -8,6 +8,11 @@ import (
 
 func GenerateOutputFileName(input, output string) string {
 	filename, _ := strings.CutSuffix(path.Base(input), ".go")
-	subTree := strings.ReplaceAll(path.Dir(input), "internal", "internal_")
-	return path.Join(output, subTree, fmt.Sprintf("%s.gen.go", filename))
+	return path.Join(GenerateOutputFolder(path.Dir(input), output), fmt.Sprintf("%s.gen.go", filename))
+}
+
+// GenerateOutputFolder returns the folder for the mocks of the package on dir.
+// Internal folders are renamed, so the mocks can be imported from anywhere.
+func GenerateOutputFolder(dir, output string) string {
+	return path.Join(output, strings.ReplaceAll(dir, "internal", "internal_"))
 }
@@ -3,28 +3,31 @@ package files
 import (
 	"fmt"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
-	"golang.org/x/mod/modfile"
+	"github.com/sonalys/fake/internal/platform"
 )
 
-// ListGoFiles lists all Go files under a directory.
-func ListGoFiles(dirs, ignore []string) ([]string, error) {
+// ListGoFiles lists all Go files under a directory, skipping the files excluded by the build settings.
+func ListGoFiles(settings platform.Settings, dirs, ignore []string) ([]string, error) {
 	var goFiles []string
 	for _, dir := range dirs {
 		err := filepath.Walk(dir, func(filename string, info os.FileInfo, err error) error {
-			if err != nil || info.IsDir() {
+			if err != nil {
 				return nil
 			}
-			for _, entry := range ignore {
-				if matched, _ := path.Match(entry, dir); matched {
-					return nil
+			if Ignored(filename, ignore) {
+				if info.IsDir() {
+					return filepath.SkipDir
 				}
+				return nil
+			}
+			// Nested modules are scanned as their own inputs.
+			if info.IsDir() && filename != dir && fileExists(filepath.Join(filename, "go.mod")) {
+				return filepath.SkipDir
 			}
-			// Check if the file has a ".go" extension
-			if strings.HasSuffix(info.Name(), ".go") && !strings.HasSuffix(info.Name(), "_test.go") && !strings.HasSuffix(info.Name(), ".gen.go") {
+			if !info.IsDir() && IsGoFile(filename) && settings.MatchFile(filename) {
 				goFiles = append(goFiles, filename)
 			}
 			return nil
@@ -36,6 +39,35 @@ func ListGoFiles(dirs, ignore []string) ([]string, error) {
 	return goFiles, nil
 }
 
+// IsGoFile reports whether the file is a Go source file that can declare interfaces to be mocked.
+// Tests and generated mocks are not.
+func IsGoFile(filename string) bool {
+	name := filepath.Base(filename)
+	return strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") && !strings.HasSuffix(name, ".gen.go")
+}
+
+// Ignored reports whether the path matches any of the ignore patterns.
+// Relative paths and patterns are resolved from the working directory.
+func Ignored(filename string, ignore []string) bool {
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		return false
+	}
+	for _, entry := range ignore {
+		if entry == "" {
+			continue
+		}
+		pattern, err := filepath.Abs(entry)
+		if err != nil {
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, absPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // fileExists checks if a file exists at the given path.
 func fileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -81,19 +113,6 @@ func FindFile(childDir, fileName string) (string, error) {
 	return "", fmt.Errorf("%s file not found", fileName)
 }
 
-// GetPackagePath returns the absolute path of the file package, including the module path.
-// This function is not considering packages with different names from their respective folders,
-// the reason is that this software is not made for psychopaths.
-func GetPackagePath(goModPath string, modFile *modfile.File, filename string) (string, error) {
-	// Retrieve the module path
-	modulePath := modFile.Module.Mod.Path
-	pkgPath, err := GetRelativePath(goModPath, path.Dir(filename))
-	if err != nil {
-		return "", err
-	}
-	return path.Join(modulePath, pkgPath), nil
-}
-
 // GetRelativePath returns the shared path between two paths.
 // if they are in the same folder, they will return the same folder path.
 // Example: /path1/folder1/file and /path1/folder2/file2 should return /path1.
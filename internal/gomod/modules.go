@@ -0,0 +1,97 @@
+package gomod
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sonalys/fake/internal/files"
+	"golang.org/x/mod/modfile"
+)
+
+// Module is a module with inputs to be scanned for interfaces.
+type Module struct {
+	// Dir is the absolute path of the folder with the go.mod file.
+	Dir string
+	// Path is the module path, like github.com/sonalys/fake.
+	Path   string
+	Inputs []string
+}
+
+// FindModules groups the inputs by the module they belong to, keeping the order of the inputs.
+// Inputs with a go.work file are expanded into the modules used by the workspace.
+func FindModules(inputs []string) ([]*Module, error) {
+	var modules []*Module
+	byDir := make(map[string]*Module)
+	add := func(goModPath, input string) error {
+		dir := filepath.Dir(goModPath)
+		if m, ok := byDir[dir]; ok {
+			m.Inputs = append(m.Inputs, input)
+			return nil
+		}
+		content, err := os.ReadFile(goModPath)
+		if err != nil {
+			return err
+		}
+		m := &Module{
+			Dir:    dir,
+			Path:   modfile.ModulePath(content),
+			Inputs: []string{input},
+		}
+		byDir[dir] = m
+		modules = append(modules, m)
+		return nil
+	}
+	for _, input := range inputs {
+		uses, err := workspaceModules(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range uses {
+			if err := add(filepath.Join(dir, "go.mod"), dir); err != nil {
+				return nil, err
+			}
+		}
+		if uses != nil {
+			continue
+		}
+		goModPath, err := files.FindFile(input, "go.mod")
+		if err != nil {
+			return nil, fmt.Errorf("input %s is not part of a go module: %w", input, err)
+		}
+		if err := add(goModPath, input); err != nil {
+			return nil, err
+		}
+	}
+	return modules, nil
+}
+
+// workspaceModules returns the folders of the modules inside dir, used by the go.work file from dir or its parents.
+// It returns nil if dir is not part of a workspace, or if it has no modules from the workspace.
+func workspaceModules(dir string) ([]string, error) {
+	goWorkPath, err := files.FindFile(dir, "go.work")
+	if err != nil {
+		return nil, nil
+	}
+	content, err := os.ReadFile(goWorkPath)
+	if err != nil {
+		return nil, err
+	}
+	workFile, err := modfile.ParseWork(goWorkPath, content, nil)
+	if err != nil {
+		return nil, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var uses []string
+	for _, use := range workFile.Use {
+		useDir := filepath.Join(filepath.Dir(goWorkPath), filepath.FromSlash(use.Path))
+		// Modules outside dir are not requested, inputs inside a module are found from its go.mod instead.
+		if rel, err := filepath.Rel(absDir, useDir); err == nil && filepath.IsLocal(rel) {
+			uses = append(uses, useDir)
+		}
+	}
+	return uses, nil
+}
@@ -2,8 +2,10 @@ package gosum
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/sonalys/fake/internal/files"
@@ -41,11 +43,15 @@ func Parse(dir string) (map[string]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not parse go.mod: %w", err)
 	}
-	goSumPath, err := files.FindFile(dir, "go.sum")
+	goModPath, err := files.FindFile(dir, "go.mod")
 	if err != nil {
-		return nil, fmt.Errorf("could not find go.sum: %w", err)
+		return nil, fmt.Errorf("could not find go.mod: %w", err)
+	}
+	// The go.sum file is always next to go.mod, modules without dependencies don't have one.
+	dependenciesParsed, err := readGoSum(filepath.Join(filepath.Dir(goModPath), "go.sum"), goMod)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
 	}
-	dependenciesParsed, err := readGoSum(goSumPath, goMod)
 	if err != nil {
 		return nil, fmt.Errorf("could not read go.sum: %w", err)
 	}
@@ -0,0 +1,103 @@
+package imports
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+type (
+	// ImportEntry is an import from a generated file.
+	ImportEntry struct {
+		// Name is the package name.
+		Name string
+		Path string
+		// Alias is the name used to reference the package in the generated file.
+		Alias string
+	}
+
+	// Set resolves the names used to reference each package from a generated file, avoiding collisions.
+	Set struct {
+		byName map[string]*ImportEntry
+		byPath map[string]*ImportEntry
+		used   map[string]struct{}
+		// local is the path of the package of the generated file, which is never imported.
+		local string
+	}
+)
+
+// NewSet creates an import set, reserved names are never used as aliases.
+func NewSet(reserved ...string) *Set {
+	s := &Set{
+		byName: make(map[string]*ImportEntry),
+		byPath: make(map[string]*ImportEntry),
+		used:   make(map[string]struct{}),
+	}
+	for _, name := range reserved {
+		s.byName[name] = &ImportEntry{Name: name, Alias: name}
+	}
+	return s
+}
+
+// Reserve registers the preferred alias for a package, without marking its import as used.
+// It's used to keep the aliases from the original file, an empty alias defaults to the package name.
+func (s *Set) Reserve(path, name, alias string) *ImportEntry {
+	if entry, ok := s.byPath[path]; ok {
+		return entry
+	}
+	if alias == "" {
+		alias = name
+	}
+	// If different packages collide with same name, we alias the new one with a suffix.
+	for i, base := 1, alias; ; i++ {
+		if _, collides := s.byName[alias]; !collides {
+			break
+		}
+		alias = fmt.Sprintf("%s%d", base, i)
+	}
+	entry := &ImportEntry{
+		Name:  name,
+		Path:  path,
+		Alias: alias,
+	}
+	s.byName[alias] = entry
+	s.byPath[path] = entry
+	return entry
+}
+
+// Local sets the package of the generated file, its types are referenced without alias.
+func (s *Set) Local(path string) {
+	s.local = path
+}
+
+// Use returns the alias for the given package, marking its import as used.
+// The local package has an empty alias.
+func (s *Set) Use(path, name string) string {
+	if path == s.local {
+		return ""
+	}
+	entry := s.Reserve(path, name, "")
+	s.used[path] = struct{}{}
+	return entry.Alias
+}
+
+// Has reports whether name is reserved or used as the alias of a package.
+func (s *Set) Has(name string) bool {
+	_, ok := s.byName[name]
+	return ok
+}
+
+// Qualifier is a types.Qualifier, qualifying types with their package alias and marking their imports as used.
+func (s *Set) Qualifier(pkg *types.Package) string {
+	return s.Use(pkg.Path(), pkg.Name())
+}
+
+// Used returns all the imports used, sorted by path.
+func (s *Set) Used() []*ImportEntry {
+	resp := make([]*ImportEntry, 0, len(s.used))
+	for path := range s.used {
+		resp = append(resp, s.byPath[path])
+	}
+	sort.Slice(resp, func(i, j int) bool { return resp[i].Path < resp[j].Path })
+	return resp
+}
@@ -0,0 +1,40 @@
+package imports
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Set(t *testing.T) {
+	s := NewSet("mockSetup")
+	s.Reserve("github.com/stretchr/testify/require", "require", "stub")
+	s.Reserve("time", "time", "")
+
+	require.Equal(t, "stub1", s.Use("github.com/sonalys/fake/testdata", "stub"))
+	require.Equal(t, "stub", s.Use("github.com/stretchr/testify/require", "require"))
+	require.Equal(t, "context", s.Qualifier(types.NewPackage("context", "context")))
+	require.Equal(t, "mockSetup1", s.Use("example.com/mockSetup", "mockSetup"))
+	// Same path returns the same alias.
+	require.Equal(t, "stub1", s.Use("github.com/sonalys/fake/testdata", "stub"))
+
+	exp := []*ImportEntry{
+		{Name: "context", Path: "context", Alias: "context"},
+		{Name: "mockSetup", Path: "example.com/mockSetup", Alias: "mockSetup1"},
+		{Name: "stub", Path: "github.com/sonalys/fake/testdata", Alias: "stub1"},
+		{Name: "require", Path: "github.com/stretchr/testify/require", Alias: "stub"},
+	}
+	// Reserved but unused imports are not listed.
+	require.Equal(t, exp, s.Used())
+}
+
+func Test_Set_Local(t *testing.T) {
+	s := NewSet("mockSetup")
+	s.Local("example.com/store")
+
+	require.Empty(t, s.Use("example.com/store", "store"))
+	require.Equal(t, "Key", types.TypeString(types.NewNamed(types.NewTypeName(0, types.NewPackage("example.com/store", "store"), "Key", nil), types.Typ[types.String], nil), s.Qualifier))
+	// The local package is never imported.
+	require.Empty(t, s.Used())
+}
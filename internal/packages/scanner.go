@@ -1,18 +1,26 @@
 package packages
 
-import "golang.org/x/tools/go/packages"
+import (
+	"github.com/sonalys/fake/internal/platform"
+	"golang.org/x/tools/go/packages"
+)
 
 type PackageInfo struct {
 	Name  string
 	Path  string
 	Files []string
+	// Module is the module path and version of the package, like golang.org/x/tools@v0.34.0.
+	// Packages from the standard library use std.
+	Module string
 }
 
 // Parse parses the specified package and returns its package name and import path.
-func Parse(dir, importPath string) (*PackageInfo, bool) {
+func Parse(settings platform.Settings, dir, importPath string) (*PackageInfo, bool) {
 	cfg := &packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles,
-		Dir:  dir,
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedModule,
+		Dir:        dir,
+		Env:        settings.Env(),
+		BuildFlags: settings.BuildFlags(),
 	}
 	pkgs, err := packages.Load(cfg, importPath)
 	if err != nil {
@@ -23,9 +31,26 @@ func Parse(dir, importPath string) (*PackageInfo, bool) {
 	}
 	// Assuming the first package is the main package, you might need to adjust this logic.
 	mainPkg := pkgs[0]
+	if len(mainPkg.Errors) > 0 {
+		return nil, false
+	}
 	return &PackageInfo{
-		Name:  mainPkg.Name,
-		Path:  mainPkg.PkgPath,
-		Files: mainPkg.GoFiles,
+		Name:   mainPkg.Name,
+		Path:   mainPkg.PkgPath,
+		Files:  mainPkg.GoFiles,
+		Module: getModule(mainPkg.Module),
 	}, true
 }
+
+func getModule(module *packages.Module) string {
+	switch {
+	case module == nil:
+		return "std"
+	case module.Replace != nil:
+		return getModule(module.Replace)
+	case module.Version == "":
+		return module.Path
+	default:
+		return module.Path + "@" + module.Version
+	}
+}
@@ -0,0 +1,129 @@
+// Package platform holds the build settings used to select source files and load packages,
+// and the build constraints copied onto generated files.
+package platform
+
+import (
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Settings are the build tags and target platform, empty values default to the ones from the go command.
+type Settings struct {
+	Tags   []string
+	GOOS   string
+	GOARCH string
+}
+
+// Context returns the build context used to evaluate the constraints of files.
+func (s Settings) Context() *build.Context {
+	ctx := build.Default
+	if s.GOOS != "" {
+		ctx.GOOS = s.GOOS
+	}
+	if s.GOARCH != "" {
+		ctx.GOARCH = s.GOARCH
+	}
+	ctx.BuildTags = s.Tags
+	return &ctx
+}
+
+// Env returns the environment for the go command, or nil to use the current one.
+func (s Settings) Env() []string {
+	if s.GOOS == "" && s.GOARCH == "" {
+		return nil
+	}
+	env := os.Environ()
+	if s.GOOS != "" {
+		env = append(env, "GOOS="+s.GOOS)
+	}
+	if s.GOARCH != "" {
+		env = append(env, "GOARCH="+s.GOARCH)
+	}
+	return env
+}
+
+// BuildFlags returns the flags for the go command.
+func (s Settings) BuildFlags() []string {
+	if len(s.Tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(s.Tags, ",")}
+}
+
+// MatchFile reports whether the file is part of its package with the given settings.
+func (s Settings) MatchFile(filename string) bool {
+	match, err := s.Context().MatchFile(filepath.Dir(filename), filepath.Base(filename))
+	return err == nil && match
+}
+
+// Constraint returns the build constraint of a file, combining its //go:build line with the constraints implied by its name,
+// like the operating system from foo_windows.go. It returns nil for files built on every platform.
+func Constraint(filename string, file *ast.File) constraint.Expr {
+	var expr constraint.Expr
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if !constraint.IsGoBuild(comment.Text) {
+				continue
+			}
+			if parsed, err := constraint.Parse(comment.Text); err == nil {
+				expr = and(expr, parsed)
+			}
+		}
+	}
+	for _, tag := range nameTags(filepath.Base(filename)) {
+		expr = and(expr, &constraint.TagExpr{Tag: tag})
+	}
+	return expr
+}
+
+func and(x, y constraint.Expr) constraint.Expr {
+	if x == nil {
+		return y
+	}
+	return &constraint.AndExpr{X: x, Y: y}
+}
+
+// nameTags returns the operating system and architecture implied by the file name, following the rules from go/build.
+func nameTags(name string) []string {
+	if dot := strings.Index(name, "."); dot != -1 {
+		name = name[:dot]
+	}
+	// Everything before the first _ is ignored, so windows.go has no constraints.
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return nil
+	}
+	parts := strings.Split(name[i:], "_")
+	if n := len(parts); n > 0 && parts[n-1] == "test" {
+		parts = parts[:n-1]
+	}
+	n := len(parts)
+	switch {
+	case n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]]:
+		return []string{parts[n-2], parts[n-1]}
+	case n >= 1 && (knownOS[parts[n-1]] || knownArch[parts[n-1]]):
+		return []string{parts[n-1]}
+	}
+	return nil
+}
+
+// knownOS and knownArch are the values of GOOS and GOARCH recognized in file names, as listed by go/build.
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true, "hurd": true,
+	"illumos": true, "ios": true, "js": true, "linux": true, "nacl": true, "netbsd": true,
+	"openbsd": true, "plan9": true, "solaris": true, "wasip1": true, "windows": true, "zos": true,
+}
+
+var knownArch = map[string]bool{
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+	"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true, "mips64le": true,
+	"mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true, "riscv": true,
+	"riscv64": true, "s390": true, "s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+}
@@ -0,0 +1,135 @@
+// Package matchers provides argument matchers for expectations registered through Expect... methods.
+package matchers
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// Matcher is used to verify if an argument received by a mock satisfies an expectation.
+type Matcher interface {
+	// Match returns true if the value satisfies the matcher.
+	Match(value any) bool
+	// String describes the matcher, it is used when reporting mismatches.
+	String() string
+}
+
+type (
+	anyMatcher struct{}
+
+	eqMatcher struct {
+		expected any
+	}
+
+	notMatcher struct {
+		matcher Matcher
+	}
+
+	regexpMatcher struct {
+		re *regexp.Regexp
+	}
+
+	lenMatcher struct {
+		length int
+	}
+
+	funcMatcher[T any] struct {
+		predicate func(T) bool
+	}
+)
+
+// Of converts a value into a Matcher.
+// If the value is already a Matcher it's returned unchanged, otherwise it's wrapped with Eq.
+func Of(value any) Matcher {
+	if m, ok := value.(Matcher); ok {
+		return m
+	}
+	return Eq(value)
+}
+
+// Any matches any value, including nil.
+func Any() Matcher { return anyMatcher{} }
+
+func (anyMatcher) Match(any) bool { return true }
+
+func (anyMatcher) String() string { return "Any()" }
+
+// Eq matches values deeply equal to the expected one, using reflect.DeepEqual.
+// Eq(nil) also matches typed nil values, like a nil pointer or slice.
+func Eq(expected any) Matcher { return eqMatcher{expected: expected} }
+
+func (m eqMatcher) Match(value any) bool {
+	if m.expected == nil {
+		return isNil(value)
+	}
+	return reflect.DeepEqual(m.expected, value)
+}
+
+func (m eqMatcher) String() string { return fmt.Sprintf("Eq(%#v)", m.expected) }
+
+// Not negates the given matcher. Literal values are wrapped with Eq.
+func Not(value any) Matcher { return notMatcher{matcher: Of(value)} }
+
+func (m notMatcher) Match(value any) bool { return !m.matcher.Match(value) }
+
+func (m notMatcher) String() string { return fmt.Sprintf("Not(%s)", m.matcher) }
+
+// Regexp matches strings, byte slices and fmt.Stringer values against the given regular expression.
+// It panics if the expression cannot be compiled.
+func Regexp(expr string) Matcher { return regexpMatcher{re: regexp.MustCompile(expr)} }
+
+func (m regexpMatcher) Match(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return m.re.MatchString(v)
+	case []byte:
+		return m.re.Match(v)
+	case fmt.Stringer:
+		return m.re.MatchString(v.String())
+	}
+	return false
+}
+
+func (m regexpMatcher) String() string { return fmt.Sprintf("Regexp(%q)", m.re) }
+
+// Len matches arrays, channels, maps, slices and strings with the given length.
+func Len(length int) Matcher { return lenMatcher{length: length} }
+
+func (m lenMatcher) Match(value any) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == m.length
+	}
+	return false
+}
+
+func (m lenMatcher) String() string { return fmt.Sprintf("Len(%d)", m.length) }
+
+// Func matches values of type T satisfying the given predicate.
+// Values from a different type never match.
+func Func[T any](predicate func(T) bool) Matcher { return funcMatcher[T]{predicate: predicate} }
+
+func (m funcMatcher[T]) Match(value any) bool {
+	v, ok := value.(T)
+	// Untyped nil is still a valid T when T is nilable, like a pointer or an interface.
+	if !ok && (value != nil || !isNil(any(v))) {
+		return false
+	}
+	return m.predicate(v)
+}
+
+func (m funcMatcher[T]) String() string { return fmt.Sprintf("Func(%T)", m.predicate) }
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
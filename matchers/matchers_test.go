@@ -0,0 +1,56 @@
+package matchers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Matchers(t *testing.T) {
+	var nilPtr *int
+	tests := []struct {
+		name    string
+		matcher Matcher
+		value   any
+		match   bool
+	}{
+		{name: "any", matcher: Any(), value: 1, match: true},
+		{name: "any nil", matcher: Any(), value: nil, match: true},
+		{name: "eq", matcher: Eq("a"), value: "a", match: true},
+		{name: "eq different", matcher: Eq("a"), value: "b", match: false},
+		{name: "eq different types", matcher: Eq(1), value: int64(1), match: false},
+		{name: "eq deep", matcher: Eq([]int{1, 2}), value: []int{1, 2}, match: true},
+		{name: "eq nil", matcher: Eq(nil), value: nil, match: true},
+		{name: "eq typed nil", matcher: Eq(nil), value: nilPtr, match: true},
+		{name: "eq nil with value", matcher: Eq(nil), value: 0, match: false},
+		{name: "not", matcher: Not("a"), value: "b", match: true},
+		{name: "not matcher", matcher: Not(Any()), value: "b", match: false},
+		{name: "regexp", matcher: Regexp("^user-[0-9]+$"), value: "user-10", match: true},
+		{name: "regexp bytes", matcher: Regexp("^user"), value: []byte("user"), match: true},
+		{name: "regexp stringer", matcher: Regexp("^1s$"), value: time.Second, match: true},
+		{name: "regexp mismatch", matcher: Regexp("^user"), value: "admin", match: false},
+		{name: "regexp invalid type", matcher: Regexp("1"), value: 1, match: false},
+		{name: "len slice", matcher: Len(2), value: []int{1, 2}, match: true},
+		{name: "len map", matcher: Len(1), value: map[int]int{1: 1}, match: true},
+		{name: "len string", matcher: Len(3), value: "ab", match: false},
+		{name: "len invalid type", matcher: Len(0), value: 0, match: false},
+		{name: "func", matcher: Func(func(v int) bool { return v > 1 }), value: 2, match: true},
+		{name: "func false", matcher: Func(func(v int) bool { return v > 1 }), value: 1, match: false},
+		{name: "func different type", matcher: Func(func(v int) bool { return true }), value: "1", match: false},
+		{name: "func nil interface", matcher: Func(func(v error) bool { return v == nil }), value: nil, match: true},
+		{name: "func nil non-nilable", matcher: Func(func(v int) bool { return true }), value: nil, match: false},
+		{name: "func interface", matcher: Func(func(v error) bool { return v != nil }), value: errors.New("err"), match: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.match, tt.matcher.Match(tt.value), tt.matcher.String())
+		})
+	}
+}
+
+func Test_Of(t *testing.T) {
+	require.Equal(t, Any(), Of(Any()))
+	require.Equal(t, Eq(1), Of(1))
+}
@@ -2,32 +2,41 @@ package fake
 
 import (
 	"fmt"
-	"go/ast"
+	"go/types"
 	"io"
+	"strings"
 )
 
-func (f *ParsedInterface) WriteMethodParams(implFile io.Writer, params []*ast.Field) {
-	fmt.Fprintf(implFile, "(")
-	f.PrintAstFields(implFile, params, true)
-	fmt.Fprintf(implFile, ")")
+func (f *ParsedInterface) WriteMethodParams(implFile io.Writer, field *ParsedField) {
+	names, paramTypes := field.ParamNames(), field.ParamTypes()
+	params := make([]string, 0, len(names))
+	for i := range names {
+		typeName := paramTypes[i]
+		if field.Signature.Variadic() && i == len(names)-1 {
+			variadic := field.Signature.Params().At(i).Type().(*types.Slice)
+			typeName = "..." + f.ParsedFile.printType(variadic.Elem())
+		}
+		params = append(params, fmt.Sprintf("%s %s", names[i], typeName))
+	}
+	fmt.Fprintf(implFile, "(%s)", strings.Join(params, ", "))
 }
 
-func (f *ParsedInterface) WriteMethodResults(implFile io.Writer, results []*ast.Field) {
+func (f *ParsedInterface) WriteMethodResults(implFile io.Writer, field *ParsedField) {
+	results := field.ResultTypes()
 	if len(results) == 0 {
 		return
 	}
-	fmt.Fprintf(implFile, " (")
-	f.PrintAstFields(implFile, results, false)
-	fmt.Fprintf(implFile, ") ")
+	if field.NamedResults() {
+		names := field.ResultNames()
+		for i := range results {
+			results[i] = fmt.Sprintf("%s %s", names[i], results[i])
+		}
+	}
+	fmt.Fprintf(implFile, " (%s) ", strings.Join(results, ", "))
 }
 
 func (f *ParsedInterface) PrintMethodHeader(file io.Writer, methodName string, field *ParsedField) {
 	fmt.Fprint(file, methodName)
-	funcType := field.Ref.Type.(*ast.FuncType)
-	if fields := funcType.Params; fields != nil {
-		field.Interface.WriteMethodParams(file, fields.List)
-	}
-	if fields := funcType.Results; fields != nil {
-		field.Interface.WriteMethodResults(file, fields.List)
-	}
+	field.Interface.WriteMethodParams(file, field)
+	field.Interface.WriteMethodResults(file, field)
 }
@@ -0,0 +1,125 @@
+package fake
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+	"github.com/sonalys/fake/internal/files"
+)
+
+// outputWriter receives the files from a run, so it can be checked without touching the filesystem.
+// Implementations must be safe for concurrent use.
+type outputWriter interface {
+	WriteFile(filename string, data []byte)
+	RemoveFile(filename string)
+}
+
+// diskWriter applies the files to the filesystem.
+type diskWriter struct{}
+
+func (diskWriter) WriteFile(filename string, data []byte) {
+	outFile, err := files.CreateFileAndFolders(filename)
+	if err != nil {
+		log.Panic().Msgf("error creating mock file: %v\n", err)
+	}
+	defer outFile.Close()
+	if _, err := outFile.Write(data); err != nil {
+		log.Panic().Msgf("error writing mock file: %v\n", err)
+	}
+}
+
+func (diskWriter) RemoveFile(filename string) {
+	if err := os.Remove(filename); err == nil {
+		log.Info().Msgf("removing legacy mock from %s", filename)
+	}
+}
+
+// checkWriter keeps the files from a run in memory, to compare them with the ones on disk.
+// Removed files are stored as nil.
+type checkWriter struct {
+	lock  sync.Mutex
+	files map[string][]byte
+}
+
+func newCheckWriter() *checkWriter {
+	return &checkWriter{
+		files: make(map[string][]byte),
+	}
+}
+
+func (c *checkWriter) WriteFile(filename string, data []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.files[filename] = bytes.Clone(data)
+}
+
+func (c *checkWriter) RemoveFile(filename string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.files[filename]; !ok {
+		c.files[filename] = nil
+	}
+}
+
+// diff writes an unified diff from the disk to the expected content of each stale file.
+// It returns how many files are stale.
+func (c *checkWriter) diff(w io.Writer) (int, error) {
+	filenames := make([]string, 0, len(c.files))
+	for filename := range c.files {
+		filenames = append(filenames, filename)
+	}
+	slices.Sort(filenames)
+	var stale int
+	for _, filename := range filenames {
+		expected := c.files[filename]
+		current, err := os.ReadFile(filename)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return stale, err
+		}
+		if bytes.Equal(current, expected) {
+			continue
+		}
+		stale++
+		fromFile, toFile := filename, filename
+		switch {
+		case current == nil:
+			fromFile = "/dev/null"
+		case expected == nil:
+			toFile = "/dev/null"
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(current),
+			B:        splitLines(expected),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		if err != nil {
+			return stale, err
+		}
+		fmt.Fprint(w, diff)
+	}
+	return stale, nil
+}
+
+// splitLines splits the content in lines, always ending them with a line break as expected by difflib.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if last := len(lines) - 1; lines[last] == "" {
+		lines = lines[:last]
+	} else {
+		lines[last] += "\n"
+	}
+	return lines
+}
@@ -1,31 +1,245 @@
 package fake
 
 import (
-	"go/parser"
+	"errors"
+	"fmt"
+	"go/ast"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/sonalys/fake/internal/files"
+	"github.com/sonalys/fake/internal/imports"
+	"golang.org/x/tools/go/packages"
 )
 
+// loadMode only parses the requested packages, types from their dependencies are read from the compiler export data.
+// The import graph is still loaded, so packages can be invalidated when their dependencies change.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedTypes | packages.NeedSyntax | packages.NeedImports
+
+// errFileNotLoaded is returned for files that are not part of their package, like files excluded by build constraints.
+var errFileNotLoaded = errors.New("file is not part of the loaded package")
+
+// packageKey identifies a loaded package by the folder it was loaded from and its pattern.
+type packageKey struct {
+	dir, pattern string
+}
+
+// loadedPackage is a package cache entry, loaded only once even if requested concurrently.
+type loadedPackage struct {
+	once sync.Once
+	pkg  *packages.Package
+	err  error
+}
+
+// cachedPackage returns the cache entry for the key, creating it if needed.
+func (g *Generator) cachedPackage(key packageKey) *loadedPackage {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	entry, ok := g.packages[key]
+	if !ok {
+		entry = &loadedPackage{}
+		g.packages[key] = entry
+	}
+	return entry
+}
+
+// packagesConfig returns the config for loading packages from dir, following the build settings.
+func (g *Generator) packagesConfig(dir string) *packages.Config {
+	return &packages.Config{
+		Mode:       loadMode,
+		Dir:        dir,
+		Fset:       g.FileSet,
+		Env:        g.Build.Env(),
+		BuildFlags: g.Build.BuildFlags(),
+	}
+}
+
+// loadPackage loads the package matching pattern from dir with its syntax and type information.
+// Packages are cached, so each package is only type-checked once.
+func (g *Generator) loadPackage(dir, pattern string) (*packages.Package, error) {
+	entry := g.cachedPackage(packageKey{dir, pattern})
+	entry.once.Do(func() {
+		entry.pkg, entry.err = g.load(dir, pattern)
+	})
+	return entry.pkg, entry.err
+}
+
+func (g *Generator) load(dir, pattern string) (*packages.Package, error) {
+	pkgs, err := packages.Load(g.packagesConfig(dir), pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 || pkgs[0].Types == nil {
+		return nil, fmt.Errorf("no package %s found on %s", pattern, dir)
+	}
+	pkg := pkgs[0]
+	for _, err := range pkg.Errors {
+		log.Warn().Msgf("package %s has errors: %s", pkg.PkgPath, err)
+	}
+	return pkg, nil
+}
+
+// LoadPackages loads the packages of all files at once, which is much faster than loading them one by one.
+// Packages that fail to load are retried individually when their files are parsed.
+func (g *Generator) LoadPackages(filenames ...string) error {
+	// Packages are loaded from their own module, as inputs can come from different modules.
+	var moduleDirs []string
+	patterns := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, filename := range filenames {
+		absPath, err := filepath.Abs(filename)
+		if err != nil {
+			return err
+		}
+		dir := filepath.Dir(absPath)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		goModPath, err := files.FindFile(dir, "go.mod")
+		if err != nil {
+			return err
+		}
+		moduleDir := filepath.Dir(goModPath)
+		relPath, err := filepath.Rel(moduleDir, dir)
+		if err != nil {
+			return err
+		}
+		if _, ok := patterns[moduleDir]; !ok {
+			moduleDirs = append(moduleDirs, moduleDir)
+		}
+		patterns[moduleDir] = append(patterns[moduleDir], "./"+filepath.ToSlash(relPath))
+	}
+	for _, moduleDir := range moduleDirs {
+		if err := g.loadModulePackages(moduleDir, patterns[moduleDir]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadModulePackages loads the packages matching patterns from the module on moduleDir into the cache.
+func (g *Generator) loadModulePackages(moduleDir string, patterns ...string) error {
+	pkgs, err := packages.Load(g.packagesConfig(moduleDir), patterns...)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range pkgs {
+		if pkg.Types == nil || len(pkg.GoFiles) == 0 || len(pkg.Errors) > 0 {
+			continue
+		}
+		entry := g.cachedPackage(packageKey{filepath.Dir(pkg.GoFiles[0]), "."})
+		entry.once.Do(func() {
+			entry.pkg = pkg
+		})
+	}
+	return nil
+}
+
+// invalidate drops the packages from the given directories, and the packages importing them, so they are loaded again.
+// It must not be called while files are being generated.
+func (g *Generator) invalidate(dirs ...string) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	seen := make(map[string]bool)
+	for key, entry := range g.packages {
+		if entry.pkg == nil || dependsOn(entry.pkg, dirs, seen) {
+			delete(g.packages, key)
+		}
+	}
+	for filename := range g.comments {
+		if slices.Contains(dirs, filepath.Dir(filename)) {
+			delete(g.comments, filename)
+		}
+	}
+}
+
+// dependsOn reports whether the package, or any of its imports, is from one of the directories.
+func dependsOn(pkg *packages.Package, dirs []string, seen map[string]bool) bool {
+	if result, ok := seen[pkg.ID]; ok {
+		return result
+	}
+	seen[pkg.ID] = false
+	result := len(pkg.GoFiles) > 0 && slices.Contains(dirs, filepath.Dir(pkg.GoFiles[0]))
+	for _, imported := range pkg.Imports {
+		if result {
+			break
+		}
+		result = dependsOn(imported, dirs, seen)
+	}
+	seen[pkg.ID] = result
+	return result
+}
+
 func (g *Generator) ParseFile(input string) (*ParsedFile, error) {
-	file, err := parser.ParseFile(g.FileSet, input, nil, parser.SkipObjectResolution)
+	absPath, err := filepath.Abs(input)
 	if err != nil {
 		return nil, err
 	}
-	packagePath, err := files.GetPackagePath(g.goModFilename, g.goMod, input)
+	pkg, err := g.loadPackage(filepath.Dir(absPath), ".")
 	if err != nil {
-		log.Error().Err(err).Msg("failed to get package info")
+		log.Error().Err(err).Msg("failed to load package")
 		return nil, err
 	}
-	imports, importsPathMap := g.cachedPackageInfo(file)
-	return &ParsedFile{
-		Generator:      g,
-		Ref:            file,
-		Size:           int(file.End()),
-		PkgPath:        packagePath,
-		PkgName:        file.Name.Name,
-		Imports:        imports,
-		ImportsPathMap: importsPathMap,
-		UsedImports:    make(map[string]struct{}),
-	}, nil
+	for _, file := range pkg.Syntax {
+		if g.FileSet.File(file.Pos()).Name() == absPath {
+			return g.parseSyntax(pkg, file), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", errFileNotLoaded, input)
+}
+
+// parseSyntax creates a parsed file from one of the package files.
+func (g *Generator) parseSyntax(pkg *packages.Package, file *ast.File) *ParsedFile {
+	importNames := make(map[string]string)
+	for _, imported := range pkg.Types.Imports() {
+		importNames[imported.Path()] = imported.Name()
+	}
+	// Aliases from the original file are kept in the generated file.
+	importSet := imports.NewSet("mockSetup")
+	for _, spec := range file.Imports {
+		importPath := strings.Trim(spec.Path.Value, "\"")
+		name, ok := importNames[importPath]
+		if !ok {
+			name = path.Base(importPath)
+		}
+		var alias string
+		if spec.Name != nil && spec.Name.Name != "." && spec.Name.Name != "_" {
+			alias = spec.Name.Name
+		}
+		importSet.Reserve(importPath, name, alias)
+	}
+	filename := g.FileSet.File(file.Pos()).Name()
+	parsedFile := &ParsedFile{
+		Generator: g,
+		Ref:       file,
+		Package:   pkg.Types,
+		PkgPath:   pkg.PkgPath,
+		PkgName:   pkg.Name,
+		Imports:   importSet,
+		Config:    g.Config.Package(filepath.Dir(filename)),
+	}
+	// Importing the package of the interfaces from itself would be an import cycle.
+	if g.InPackage && g.mockPackageName(parsedFile) == parsedFile.PkgName {
+		importSet.Local(parsedFile.PkgPath)
+	}
+	return parsedFile
+}
+
+// ParsePackage parses all files from the package with the given import path, as resolved from the module.
+func (g *Generator) ParsePackage(importPath string) ([]*ParsedFile, error) {
+	pkg, err := g.loadPackage(filepath.Dir(g.goModFilename), importPath)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*ParsedFile, 0, len(pkg.Syntax))
+	for _, file := range pkg.Syntax {
+		resp = append(resp, g.parseSyntax(pkg, file))
+	}
+	return resp, nil
 }
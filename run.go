@@ -1,13 +1,27 @@
 package fake
 
 import (
+	"cmp"
 	"fmt"
+	"io"
+	"maps"
 	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"github.com/sonalys/fake/boilerplate"
 	"github.com/sonalys/fake/internal/caching"
+	"github.com/sonalys/fake/internal/config"
 	"github.com/sonalys/fake/internal/files"
+	"github.com/sonalys/fake/internal/gomod"
+	"github.com/sonalys/fake/internal/packages"
+	"github.com/sonalys/fake/internal/platform"
 )
 
 type GenerateInterfaceConfig struct {
@@ -15,10 +29,34 @@ type GenerateInterfaceConfig struct {
 	Inputs        []string
 	InterfaceName string
 	OutputFolder  string
+	Fallback      boilerplate.Fallback
+	// Config holds the project overrides for packages and interfaces, it can be nil.
+	Config *config.Config
+	// Build selects the files and platform mocks are generated for.
+	Build platform.Settings
+}
+
+type RunConfig struct {
+	Inputs      []string
+	Output      string
+	Ignore      []string
+	Interfaces  []string
+	MockPackage string
+	Fallback    boilerplate.Fallback
+	// External lists the interfaces to be generated from packages outside the module.
+	External []config.External
+	// ExternalOnly skips scanning the inputs, only generating the external packages.
+	ExternalOnly bool
+	// Jobs is the number of files generated concurrently, defaults to GOMAXPROCS.
+	Jobs int
+	// Config holds the project overrides for packages and interfaces, it can be nil.
+	Config *config.Config
+	// Build selects the files and platform mocks are generated for.
+	Build platform.Settings
 }
 
 func GenerateInterface(c GenerateInterfaceConfig) {
-	fileHashes, err := caching.GetUncachedFiles(c.Inputs, nil, "")
+	fileHashes, legacy, err := caching.GetUncachedFiles(c.Build, c.Inputs, nil, "")
 	if err != nil {
 		log.Fatal().Err(err).Msg("error comparing file hashes")
 	}
@@ -27,6 +65,14 @@ func GenerateInterface(c GenerateInterfaceConfig) {
 	if err != nil {
 		log.Fatal().Err(err).Msg("error creating mock generator")
 	}
+	gen.Fallback = c.Fallback
+	gen.Config = c.Config
+	gen.Build = c.Build
+	// Mocks are written next to their interfaces.
+	gen.InPackage = true
+	for _, relPath := range legacy {
+		diskWriter{}.RemoveFile(files.GenerateOutputFileName(relPath, ""))
+	}
 	for relPath, hash := range fileHashes {
 		b := gen.GenerateFile(hash.AbsolutePath(), c.InterfaceName)
 		if b == nil {
@@ -48,36 +94,215 @@ func GenerateInterface(c GenerateInterfaceConfig) {
 	}
 }
 
-func Run(inputs []string, output string, ignore []string, interfaces ...string) {
-	gen, err := NewGenerator("mocks", inputs[0])
+func newRunGenerator(c RunConfig) *Generator {
+	// Workspaces can have no module on their root, so the generator is created from the first module.
+	baseDir := c.Inputs[0]
+	if modules, err := gomod.FindModules(c.Inputs[:1]); err == nil && len(modules) > 0 {
+		baseDir = modules[0].Dir
+	}
+	gen, err := NewGenerator(cmp.Or(c.MockPackage, "mocks"), baseDir)
 	if err != nil {
 		log.Fatal().Err(err).Msg("error creating mock generator")
 	}
-	fileHashes, err := caching.GetUncachedFiles(inputs, append(ignore, output), output)
+	gen.Fallback = c.Fallback
+	gen.Config = c.Config
+	gen.Build = c.Build
+	return gen
+}
+
+func Run(c RunConfig) {
+	if run(newRunGenerator(c), c, diskWriter{}, false) == 0 {
+		log.Info().Msgf("nothing to be done")
+	}
+}
+
+// Check runs the generation in memory, comparing its files with the mocks and lock files on disk.
+// An unified diff is written to w for each stale file, and the number of stale files is returned.
+// The filesystem is never modified.
+func Check(c RunConfig, w io.Writer) (int, error) {
+	out := newCheckWriter()
+	run(newRunGenerator(c), c, out, true)
+	return out.diff(w)
+}
+
+// run generates the mocks into out, returning how many files were generated.
+// Unless force is set, files are only generated if they changed since the last run.
+func run(gen *Generator, c RunConfig, out outputWriter, force bool) int {
+	counter := generateExternal(gen, c.External, c.Output, out, force)
+	if c.ExternalOnly {
+		return counter
+	}
+	modules, err := gomod.FindModules(c.Inputs)
 	if err != nil {
-		log.Fatal().Err(err).Msg("error comparing file hashes")
+		log.Fatal().Err(err).Msg("error finding modules")
 	}
-	var counter int
+	for _, m := range modules {
+		output := c.Output
+		if len(modules) > 1 {
+			output = moduleOutput(m, modules[0], c.Output)
+		}
+		counter += runModule(gen, c, m, output, out, force)
+	}
+	return counter
+}
+
+// moduleOutput returns the output folder for the mocks of module m, when the inputs come from different modules.
+// Mocks are written inside their module, so they are built with its requirements:
+// outputs inside the first module are mirrored on the others, and relative outputs are resolved from each module folder.
+// Absolute outputs outside the first module keep the mocks from other modules under their module path.
+func moduleOutput(m, first *gomod.Module, output string) string {
+	abs, err := filepath.Abs(output)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("error resolving output %s", output)
+	}
+	if rel, err := filepath.Rel(first.Dir, abs); err == nil && filepath.IsLocal(rel) {
+		return filepath.Join(m.Dir, rel)
+	}
+	if !filepath.IsAbs(output) {
+		return filepath.Join(m.Dir, output)
+	}
+	if m == first {
+		return output
+	}
+	return files.GenerateOutputFolder(m.Path, output)
+}
+
+// runModule generates the mocks for the inputs from a module into output, which holds the module lock file.
+func runModule(gen *Generator, c RunConfig, m *gomod.Module, output string, out outputWriter, force bool) int {
+	fileHashes, legacy, err := caching.GetUncachedFiles(c.Build, m.Inputs, append(c.Ignore, c.Output), output)
+	if err != nil {
+		log.Fatal().Err(err).Msgf("error comparing file hashes from %s", m.Path)
+	}
+	for _, relPath := range legacy {
+		out.RemoveFile(gen.outputFileName(m.Dir, relPath, output))
+	}
+	var changed, filenames []string
 	for relPath, lockFile := range fileHashes {
-		if !lockFile.Changed() {
-			continue
+		if force || lockFile.Changed() {
+			changed = append(changed, relPath)
+			filenames = append(filenames, lockFile.AbsolutePath())
 		}
-		if b := gen.GenerateFile(lockFile.AbsolutePath()); len(b) > 0 {
-			log.Info().Msgf("generating mock for %s", relPath)
-			counter++
-			outputFile := openOutputFile(relPath, output)
-			outputFile.Write(b)
-			outputFile.Close()
-		} else {
-			// Remove empty files from our new lock file.
-			// delete(fileHashes, relPath)
+	}
+	if err := gen.LoadPackages(filenames...); err != nil {
+		log.Warn().Err(err).Msg("error loading packages, they will be loaded one by one")
+	}
+	var localCounter atomic.Int64
+	forEach(c.Jobs, changed, func(relPath string) {
+		start := time.Now()
+		filename := gen.outputFileName(m.Dir, relPath, output)
+		b := gen.GenerateFile(fileHashes[relPath].AbsolutePath(), c.Interfaces...)
+		if len(b) == 0 {
+			// Files that no longer declare interfaces have their mocks removed.
+			out.RemoveFile(filename)
+			return
 		}
+		log.Info().Dur("took", time.Since(start)).Msgf("generating mock for %s", relPath)
+		localCounter.Add(1)
+		out.WriteFile(filename, b)
+	})
+	if localCounter.Load() > 0 {
+		writeLockFile(out, output, fileHashes)
 	}
-	if len(fileHashes) == 0 || counter == 0 {
-		log.Info().Msgf("nothing to be done")
-		return
+	writeDocs(gen, c, m.Dir, output, out, append(changed, legacy...))
+	return int(localCounter.Load())
+}
+
+// writeDocs updates the package doc from the output folders of the given files, relative to the module on moduleDir.
+// Folders left without mocks have their package doc removed.
+func writeDocs(gen *Generator, c RunConfig, moduleDir, output string, out outputWriter, relPaths []string) {
+	sourceDirs := make(map[string]string)
+	for _, relPath := range relPaths {
+		outputDir := filepath.Dir(gen.outputFileName(moduleDir, relPath, output))
+		sourceDirs[outputDir] = filepath.Join(moduleDir, filepath.Dir(relPath))
+	}
+	outputDirs := slices.Collect(maps.Keys(sourceDirs))
+	forEach(c.Jobs, outputDirs, func(outputDir string) {
+		filename := filepath.Join(outputDir, docFilename)
+		b, err := gen.GenerateDoc(sourceDirs[outputDir], ".", c.Interfaces...)
+		if err != nil || len(b) == 0 {
+			out.RemoveFile(filename)
+			return
+		}
+		out.WriteFile(filename, b)
+	})
+}
+
+// forEach calls f for each item from a pool of workers, with up to jobs concurrent calls.
+// If jobs is not positive, GOMAXPROCS is used.
+func forEach[T any](jobs int, items []T, f func(T)) {
+	if jobs < 1 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	queue := make(chan T)
+	var wg sync.WaitGroup
+	for range min(jobs, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				f(item)
+			}
+		}()
+	}
+	for _, item := range items {
+		queue <- item
 	}
-	if err := caching.WriteLockFile(output, fileHashes); err != nil {
+	close(queue)
+	wg.Wait()
+}
+
+func writeLockFile(out outputWriter, output string, hashes map[string]caching.LockfileHandler) {
+	data, err := caching.EncodeLockFile(hashes)
+	if err != nil {
 		log.Error().Err(err).Msg("error saving lock file")
+		return
+	}
+	out.WriteFile(caching.LockFilePath(output), data)
+}
+
+// generateExternal generates mocks for packages outside the module, returning how many files were written.
+// Mocks follow the import path of their package inside the output folder, each package with its own lock file.
+func generateExternal(gen *Generator, externals []config.External, output string, out outputWriter, force bool) int {
+	var counter int
+	for _, external := range externals {
+		pkg, ok := packages.Parse(gen.Build, filepath.Dir(gen.goModFilename), external.Package)
+		if !ok {
+			log.Error().Msgf("could not find external package %s", external.Package)
+			continue
+		}
+		outputDir := files.GenerateOutputFolder(pkg.Path, output)
+		lockFile, err := caching.GetUncachedPackage(pkg, external.Interfaces, outputDir)
+		if err != nil {
+			log.Error().Err(err).Msgf("error comparing package %s hashes", pkg.Path)
+			continue
+		}
+		if !force && !lockFile.Changed() {
+			continue
+		}
+		generated, err := gen.GeneratePackage(pkg.Path, external.Interfaces...)
+		if err != nil {
+			log.Error().Err(err).Msgf("error loading external package %s", pkg.Path)
+			continue
+		}
+		// The files mocks were generated from may no longer exist.
+		legacy, err := filepath.Glob(filepath.Join(outputDir, "*.gen.go"))
+		if err != nil {
+			log.Error().Err(err).Msgf("error listing mocks from %s", outputDir)
+			continue
+		}
+		for _, filename := range legacy {
+			out.RemoveFile(filename)
+		}
+		for filename, b := range generated {
+			relPath := path.Join(pkg.Path, filepath.Base(filename))
+			log.Info().Msgf("generating mock for %s", relPath)
+			counter++
+			out.WriteFile(files.GenerateOutputFileName(relPath, output), b)
+		}
+		if b, err := gen.GenerateDoc(filepath.Dir(gen.goModFilename), pkg.Path, external.Interfaces...); err == nil && len(b) > 0 {
+			out.WriteFile(filepath.Join(outputDir, docFilename), b)
+		}
+		writeLockFile(out, outputDir, map[string]caching.LockfileHandler{pkg.Path: lockFile})
 	}
+	return counter
 }
@@ -0,0 +1,8 @@
+package stub
+
+import "context"
+
+type Fetcher interface {
+	Fetch(ctx context.Context, key string) ([]byte, error)
+	Notify(ctx context.Context)
+}
@@ -32,3 +32,54 @@ type StubInterface[T comparable] interface {
 type AnotherInterface[J, A any] interface {
 	DifferentGenericName(a J) A
 }
+
+type Clock func() time.Time
+
+type Handler[T any] func(ctx stub.Assertions, req T, opts ...string) (T, error)
+
+type NamedParams interface {
+	Grouped(a, b int, _ string, time time.Duration, s string, T bool) (n int, err error)
+	Blank(context string, _ int) (_ int, ok bool)
+}
+
+// Unexported has an unexported method, which cannot be called by spies.
+type Unexported interface {
+	Get(key string) string
+	mockSetup(x int) int
+}
+
+// Documented is a store with documented methods.
+type Documented interface {
+	// Get returns the value stored for key.
+	//
+	// Deprecated: use Lookup instead.
+	Get(key string) string
+	// Lookup returns the value stored for key,
+	// and whether it was found.
+	Lookup(key string) (string, bool)
+}
+
+// Buffer has a method named like the mock helpers.
+type Buffer interface {
+	Write(p []byte) (int, error)
+	Reset()
+}
+
+// Recorder has methods named like the mock accessors.
+type Recorder interface {
+	Calls() []string
+	Eventually(timeout time.Duration) bool
+}
+
+// Pair has a method named like the accessor of the other.
+type Pair interface {
+	Get() string
+	GetCalls() int
+}
+
+// Fallbacks has methods named like the fallback setters.
+type Fallbacks interface {
+	SetFallback(name string)
+	Get() string
+	SetGetFallback(value string)
+}
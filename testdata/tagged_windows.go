@@ -0,0 +1,7 @@
+//go:build faketag
+
+package stub
+
+type Tagged interface {
+	Tagged() string
+}
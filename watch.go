@@ -0,0 +1,96 @@
+package fake
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+	"github.com/sonalys/fake/internal/files"
+)
+
+// watchDebounce is how long the watcher waits for more changes before generating, as editors write files in bursts.
+const watchDebounce = 100 * time.Millisecond
+
+// Watch generates the mocks, then regenerates them whenever the inputs change, until ctx is done.
+// The generator is kept between runs, only reloading the packages affected by the changes.
+// External packages are only generated on the first run.
+func Watch(ctx context.Context, c RunConfig) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	ignore := append(slices.Clone(c.Ignore), c.Output)
+	for _, input := range c.Inputs {
+		if err := watchDirs(watcher, input, ignore); err != nil {
+			return err
+		}
+	}
+	gen := newRunGenerator(c)
+	run(gen, c, diskWriter{}, false)
+	c.External = nil
+	log.Info().Msgf("watching %d folders for changes", len(watcher.WatchList()))
+
+	changed := make(map[string]struct{})
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error().Err(err).Msg("error watching files")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if files.Ignored(event.Name, ignore) {
+				continue
+			}
+			absPath, err := filepath.Abs(event.Name)
+			if err != nil {
+				continue
+			}
+			if info, err := os.Stat(absPath); err == nil && info.IsDir() {
+				// Files can be created before the folder is watched, so its package is also generated.
+				if err := watchDirs(watcher, absPath, ignore); err != nil {
+					log.Error().Err(err).Msgf("error watching %s", event.Name)
+				}
+				changed[absPath] = struct{}{}
+			} else if files.IsGoFile(absPath) && event.Op != fsnotify.Chmod {
+				changed[filepath.Dir(absPath)] = struct{}{}
+			} else {
+				continue
+			}
+			debounce = time.After(watchDebounce)
+		case <-debounce:
+			debounce = nil
+			start := time.Now()
+			gen.invalidate(slices.Collect(maps.Keys(changed))...)
+			clear(changed)
+			if counter := run(gen, c, diskWriter{}, false); counter > 0 {
+				log.Info().Dur("took", time.Since(start)).Msgf("generated %d files", counter)
+			}
+		}
+	}
+}
+
+// watchDirs adds dir and its sub folders to the watcher, except the ignored ones.
+func watchDirs(watcher *fsnotify.Watcher, dir string, ignore []string) error {
+	return filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return err
+		}
+		if files.Ignored(path, ignore) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
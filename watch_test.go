@@ -0,0 +1,45 @@
+package fake
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Watch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	mockContains := func(name, content string) func() bool {
+		return func() bool {
+			b, err := os.ReadFile(filepath.Join(dir, "mocks", name))
+			return err == nil && strings.Contains(string(b), content)
+		}
+	}
+	writeFile("go.mod", "module example.com/watch\n\ngo 1.23\n")
+	writeFile("go.sum", "")
+	writeFile("a.go", "package watch\n\ntype A interface {\n\tGet() int\n}\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, RunConfig{Inputs: []string{dir}, Output: filepath.Join(dir, "mocks")})
+	}()
+	require.Eventually(t, mockContains("a.gen.go", "func (s *AMock) Get()"), 10*time.Second, 50*time.Millisecond)
+
+	writeFile("b.go", "package watch\n\ntype B interface {\n\tA\n}\n")
+	require.Eventually(t, mockContains("b.gen.go", "func (s *BMock) Get()"), 10*time.Second, 50*time.Millisecond)
+	// Packages are reloaded after changes, instead of using the ones loaded on the first run.
+	writeFile("a.go", "package watch\n\ntype A interface {\n\tGet() int\n\tSet(int)\n}\n")
+	require.Eventually(t, mockContains("a.gen.go", "func (s *AMock) Set("), 10*time.Second, 50*time.Millisecond)
+	require.Eventually(t, mockContains("doc.fake.go", "//   - B\n"), 10*time.Second, 50*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
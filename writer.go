@@ -2,16 +2,26 @@ package fake
 
 import (
 	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
+	"go/build/constraint"
 	"go/format"
 	"io"
-	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/rs/zerolog/log"
 	"github.com/sonalys/fake/internal/files"
+	"github.com/sonalys/fake/internal/platform"
+	"golang.org/x/tools/go/packages"
 )
 
+// docFilename is the name of the package doc file generated in each output folder.
+const docFilename = "doc.fake.go"
+
 var pool = sync.Pool{
 	New: func() any {
 		newBuf := make([]byte, 0, 1024*10)
@@ -21,9 +31,36 @@ var pool = sync.Pool{
 
 func (g *Generator) GenerateFile(input string, interfaceNames ...string) []byte {
 	parsedFile, err := g.ParseFile(input)
+	if errors.Is(err, errFileNotLoaded) {
+		log.Debug().Err(err).Msg("skipping file")
+		return nil
+	}
 	if err != nil {
 		log.Panic().Err(err).Msgf("failed to parse file: %s", input)
 	}
+	return g.generate(parsedFile, interfaceNames...)
+}
+
+// GeneratePackage generates the mocks for the package with the given import path, usually from outside the module.
+// The generated files are keyed by the name of their source file.
+func (g *Generator) GeneratePackage(importPath string, interfaceNames ...string) (map[string][]byte, error) {
+	parsedFiles, err := g.ParsePackage(importPath)
+	if err != nil {
+		return nil, err
+	}
+	resp := make(map[string][]byte, len(parsedFiles))
+	for _, parsedFile := range parsedFiles {
+		if b := g.generate(parsedFile, interfaceNames...); len(b) > 0 {
+			resp[g.FileSet.File(parsedFile.Ref.Pos()).Name()] = b
+		}
+	}
+	return resp, nil
+}
+
+func (g *Generator) generate(parsedFile *ParsedFile, interfaceNames ...string) []byte {
+	if parsedFile.Config.Skip {
+		return nil
+	}
 	interfaces := parsedFile.ListInterfaces(interfaceNames...)
 	if len(interfaces) == 0 {
 		return nil
@@ -34,10 +71,9 @@ func (g *Generator) GenerateFile(input string, interfaceNames ...string) []byte
 	defer pool.Put(buf2)
 	header := bytes.NewBuffer(*buf1)
 	body := bytes.NewBuffer(*buf2)
-	if g.MockPackageName == "" {
-		g.MockPackageName = parsedFile.PkgName
-	}
-	writeHeader(header, g.MockPackageName)
+	// Mocks are only built where their interfaces are defined.
+	filename := g.FileSet.File(parsedFile.Ref.Pos()).Name()
+	writeHeader(header, platform.Constraint(filename, parsedFile.Ref), g.mockPackageName(parsedFile))
 	// Iterate through the declarations in the file
 	for _, i := range interfaces {
 		i.write(body)
@@ -48,8 +84,54 @@ func (g *Generator) GenerateFile(input string, interfaceNames ...string) []byte
 	return formatCode(header.Bytes())
 }
 
-func writeHeader(w io.Writer, packageName string) {
+// mockPackageName returns the package name for the mocks generated from the file.
+func (g *Generator) mockPackageName(parsedFile *ParsedFile) string {
+	return cmp.Or(parsedFile.Config.MockPackage, g.MockPackageName, parsedFile.PkgName)
+}
+
+// GenerateDoc generates the package doc for the mocks from the package matching pattern on dir.
+// It returns nil if the package has no interfaces to be mocked.
+func (g *Generator) GenerateDoc(dir, pattern string, interfaceNames ...string) ([]byte, error) {
+	pkg, err := g.loadPackage(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return g.generateDoc(pkg, interfaceNames...), nil
+}
+
+// generateDoc writes the package comment listing the interfaces mocked from the package.
+func (g *Generator) generateDoc(pkg *packages.Package, interfaceNames ...string) []byte {
+	var names []string
+	var packageName, pkgPath string
+	for _, file := range pkg.Syntax {
+		parsedFile := g.parseSyntax(pkg, file)
+		if parsedFile.Config.Skip {
+			continue
+		}
+		for _, i := range parsedFile.ListInterfaces(interfaceNames...) {
+			names = append(names, i.Name)
+		}
+		packageName, pkgPath = g.mockPackageName(parsedFile), parsedFile.PkgPath
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	slices.Sort(names)
+	w := &bytes.Buffer{}
+	fmt.Fprintf(w, "// Code generated by fake. DO NOT EDIT.\n\n")
+	fmt.Fprintf(w, "// Package %s contains the mocks generated by fake from %s:\n", packageName, pkgPath)
+	for _, name := range names {
+		fmt.Fprintf(w, "//   - %s\n", name)
+	}
+	fmt.Fprintf(w, "package %s\n", packageName)
+	return formatCode(w.Bytes())
+}
+
+func writeHeader(w io.Writer, buildConstraint constraint.Expr, packageName string) {
 	fmt.Fprintf(w, "// Code generated by fake. DO NOT EDIT.\n\n")
+	if buildConstraint != nil {
+		fmt.Fprintf(w, "//go:build %s\n\n", buildConstraint)
+	}
 	fmt.Fprintf(w, "package %s\n\n", packageName)
 }
 
@@ -61,10 +143,13 @@ func formatCode(in []byte) []byte {
 	return out
 }
 
-func openOutputFile(input, output string) *os.File {
-	outFile, err := files.CreateFileAndFolders(files.GenerateOutputFileName(input, output))
-	if err != nil {
-		log.Panic().Msgf("error creating mock file: %v\n", err)
+// outputFileName returns where the mocks from relPath, relative to the module on moduleDir, are written.
+// Packages with their own output folder don't mirror the package tree.
+func (g *Generator) outputFileName(moduleDir, relPath, output string) string {
+	pkgConfig := g.Config.Package(filepath.Join(moduleDir, filepath.Dir(relPath)))
+	if pkgConfig.Output == "" {
+		return files.GenerateOutputFileName(relPath, output)
 	}
-	return outFile
+	filename, _ := strings.CutSuffix(filepath.Base(relPath), ".go")
+	return filepath.Join(g.Config.Path(pkgConfig.Output), fmt.Sprintf("%s.gen.go", filename))
 }